@@ -0,0 +1,233 @@
+package envi
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+)
+
+/*
+BatchLoad loads all given configs in sequence. A config that fails to load does not stop the
+remaining configs from being loaded. Configs that were loaded successfully stay loaded.
+
+The errors of all failed configs are returned together in a BatchLoadError.
+*/
+func (e *Envi) BatchLoad(configs ...any) error {
+	errs := make([]error, 0)
+
+	for _, config := range configs {
+		if err := e.Load(config); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return &BatchLoadError{Errors: errs}
+	}
+
+	return nil
+}
+
+/*
+BatchLoadAtomic loads all given configs, but only if all of them can be loaded successfully.
+If any config fails, none of the configs are changed and the errors of all failed configs
+are returned together in a BatchLoadError.
+
+The configs are first loaded into temporary deep copies to check for errors, so each file is read twice.
+If loading the configs themselves fails afterwards, e.g. because a file changed in between, the configs
+are restored and the watchers started by the batch are closed.
+*/
+func (e *Envi) BatchLoadAtomic(configs ...any) error {
+	const errMsg = "error while loading configs atomically: %w"
+
+	if err := e.tryLoad(configs); err != nil {
+		return err
+	}
+
+	backups := make([]reflect.Value, len(configs))
+
+	for i, config := range configs {
+		backups[i] = deepCopy(reflect.ValueOf(config).Elem())
+	}
+
+	state := e.saveState()
+
+	for _, config := range configs {
+		if err := e.Load(config); err != nil {
+			e.restoreState(state)
+
+			for i, config := range configs {
+				reflect.ValueOf(config).Elem().Set(backups[i])
+			}
+
+			return fmt.Errorf(errMsg, &BatchLoadError{Errors: []error{err}})
+		}
+	}
+
+	return nil
+}
+
+// tryLoad loads deep copies of the given configs with a temporary Envi instance that is closed afterwards.
+func (e *Envi) tryLoad(configs []any) error {
+	const errMsg = "error while loading configs atomically: %w"
+
+	scratch := New(e.options...)
+
+	e.mutex.RLock()
+	scratch.overrides = maps.Clone(e.overrides)
+	e.mutex.RUnlock()
+
+	defer scratch.Close()
+
+	errs := make([]error, 0)
+
+	for _, config := range configs {
+		v := reflect.ValueOf(config)
+
+		if v.Kind() != reflect.Pointer || v.IsNil() {
+			errs = append(errs, &InvalidKindError{
+				FieldName: fmt.Sprintf("%T", config),
+				Expected:  "pointer",
+				Got:       v.Kind().String(),
+			})
+
+			continue
+		}
+
+		if err := scratch.Load(deepCopy(v).Interface()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf(errMsg, &BatchLoadError{Errors: errs})
+	}
+
+	return nil
+}
+
+/*
+deepCopy returns a copy of v that shares no pointers, slices or maps reachable through exported fields with v,
+so loading into the copy leaves v untouched. Unexported fields are copied as they are.
+*/
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(deepCopy(v.Elem()))
+
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+
+		for i := range v.NumField() {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(deepCopy(v.Field(i)))
+			}
+		}
+
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+
+		for i := range v.Len() {
+			c.Index(i).Set(deepCopy(v.Index(i)))
+		}
+
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), deepCopy(iter.Value()))
+		}
+
+		return c
+	default:
+		return v
+	}
+}
+
+// loadState holds the loaded sources and the watchers of an Envi instance, so a failed batch can be rolled back.
+type loadState struct {
+	fileWatchers map[targetKey]fileWatcherInstance
+	loadedFiles  map[targetKey]fileField
+	loadedVaults map[targetKey]vaultField
+	loadOrder    []string
+}
+
+func (e *Envi) saveState() loadState {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	return loadState{
+		fileWatchers: maps.Clone(e.fileWatchers),
+		loadedFiles:  maps.Clone(e.loadedFiles),
+		loadedVaults: maps.Clone(e.loadedVaults),
+		loadOrder:    slices.Clone(e.loadOrder),
+	}
+}
+
+/*
+restoreState resets the loaded sources to the given state and closes all watchers started after it was saved.
+Saved watchers that were replaced by a load of the same field in the meantime are started again.
+*/
+func (e *Envi) restoreState(state loadState) {
+	e.mutex.Lock()
+
+	for key, instance := range e.fileWatchers {
+		if saved, ok := state.fileWatchers[key]; ok && saved.ctx == instance.ctx {
+			continue
+		}
+
+		instance.cancel()
+
+		if instance.watcher != nil {
+			instance.watcher.Close()
+		}
+	}
+
+	stopped := make([]targetKey, 0)
+
+	for key, saved := range state.fileWatchers {
+		if saved.ctx.Err() != nil {
+			delete(state.fileWatchers, key)
+			stopped = append(stopped, key)
+		}
+	}
+
+	e.fileWatchers = state.fileWatchers
+	e.loadedFiles = state.loadedFiles
+	e.loadedVaults = state.loadedVaults
+	e.loadOrder = state.loadOrder
+	e.mutex.Unlock()
+
+	if e.ctx.Err() != nil {
+		return
+	}
+
+	for _, key := range stopped {
+		if file, ok := state.loadedFiles[key]; ok {
+			if err := e.watchFile(file); err != nil {
+				e.logf("failed to restart watcher for file %s: %v", file.path, err)
+			}
+		} else if vault, ok := state.loadedVaults[key]; ok {
+			e.watchVault(vault)
+		}
+	}
+}
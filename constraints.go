@@ -0,0 +1,207 @@
+package envi
+
+import (
+	"cmp"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// patterns caches the compiled regular expressions of the "pattern" tags, so every pattern is compiled once.
+var patterns sync.Map
+
+// compilePattern returns the compiled regular expression of a "pattern" tag.
+// An InvalidTagError is returned if the pattern cannot be compiled.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, &InvalidTagError{Tag: tagPattern}
+	}
+
+	patterns.Store(pattern, re)
+
+	return re, nil
+}
+
+// checkPatterns compiles the "pattern" tags of all fields of t, including the fields of file structs and
+// embedded structs, so invalid patterns are reported when loading instead of when validating.
+// Types in seen are skipped, which stops the recursion for self-referencing types.
+func checkPatterns(t reflect.Type, seen map[reflect.Type]bool) error {
+	if seen[t] {
+		return nil
+	}
+
+	seen[t] = true
+
+	for i := range t.NumField() {
+		f := t.Field(i)
+
+		if pattern := getStructTag(f, tagPattern); pattern != "" {
+			if _, err := compilePattern(pattern); err != nil {
+				return err
+			}
+		}
+
+		if fieldType := resolveTypePointer(f.Type); isFileStruct(fieldType) {
+			if err := checkPatterns(fieldType, seen); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkConstraints checks the value of the field against its validation tags. Nil pointers are not checked,
+// the "required" tag rejects them.
+func checkConstraints(f reflect.StructField, v reflect.Value) []error {
+	v = reflect.Indirect(v)
+	if !v.IsValid() {
+		return nil
+	}
+
+	errs := make([]error, 0)
+
+	if err := checkOneOf(f, v); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := checkRange(f, v); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := checkPattern(f, v); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errs
+}
+
+// checkOneOf checks that a string value is one of the comma separated values of the "oneof" tag.
+// The "oneofci" tag compares the values case-insensitively. Empty strings are not checked.
+func checkOneOf(f reflect.StructField, v reflect.Value) error {
+	tag, caseInsensitive := getStructTag(f, tagOneOf), false
+	if tag == "" {
+		tag, caseInsensitive = getStructTag(f, tagOneOfCI), true
+	}
+
+	if tag == "" || v.Kind() != reflect.String || v.String() == "" {
+		return nil
+	}
+
+	allowed := strings.Split(tag, ",")
+
+	for i, value := range allowed {
+		allowed[i] = strings.TrimSpace(value)
+
+		if v.String() == allowed[i] || caseInsensitive && strings.EqualFold(v.String(), allowed[i]) {
+			return nil
+		}
+	}
+
+	return &InvalidValueError{FieldName: f.Name, Value: v.String(), Allowed: allowed}
+}
+
+// checkPattern checks that a string value matches the regular expression of the "pattern" tag.
+// Like regexp.MatchString, the pattern matches substrings unless it is anchored. Empty strings are not checked.
+func checkPattern(f reflect.StructField, v reflect.Value) error {
+	pattern := getStructTag(f, tagPattern)
+	if pattern == "" || v.Kind() != reflect.String || v.String() == "" {
+		return nil
+	}
+
+	re, err := compilePattern(pattern)
+	if err != nil {
+		return err
+	}
+
+	if !re.MatchString(v.String()) {
+		return &PatternMismatchError{FieldName: f.Name, Pattern: pattern, Value: v.String()}
+	}
+
+	return nil
+}
+
+/*
+checkRange checks the value against the "min" and "max" tags. For strings the tags limit the number of
+characters, for ints, uints and floats the value itself. An InvalidTagError is returned if a tag cannot be
+parsed as a number of the kind of the field.
+*/
+func checkRange(f reflect.StructField, v reflect.Value) error {
+	minTag, maxTag := getStructTag(f, tagMin), getStructTag(f, tagMax)
+	if minTag == "" && maxTag == "" {
+		return nil
+	}
+
+	var (
+		got     string
+		outside bool
+		err     error
+	)
+
+	switch v.Kind() {
+	case reflect.String:
+		length := utf8.RuneCountInString(v.String())
+		got = strconv.Itoa(length)
+		outside, err = outOfRange(length, minTag, maxTag, strconv.Atoi)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		got = strconv.FormatInt(v.Int(), 10)
+		outside, err = outOfRange(v.Int(), minTag, maxTag, func(s string) (int64, error) {
+			return strconv.ParseInt(s, 10, 64)
+		})
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		got = strconv.FormatUint(v.Uint(), 10)
+		outside, err = outOfRange(v.Uint(), minTag, maxTag, func(s string) (uint64, error) {
+			return strconv.ParseUint(s, 10, 64)
+		})
+	case reflect.Float32, reflect.Float64:
+		got = strconv.FormatFloat(v.Float(), 'g', -1, 64)
+		outside, err = outOfRange(v.Float(), minTag, maxTag, func(s string) (float64, error) {
+			return strconv.ParseFloat(s, 64)
+		})
+	default:
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if outside {
+		return &RangeViolationError{FieldName: f.Name, Min: minTag, Max: maxTag, Got: got}
+	}
+
+	return nil
+}
+
+// outOfRange parses the set bounds with parse and reports whether the value lies outside of them.
+func outOfRange[T cmp.Ordered](value T, minTag, maxTag string, parse func(string) (T, error)) (bool, error) {
+	outside := false
+
+	if minTag != "" {
+		bound, err := parse(minTag)
+		if err != nil {
+			return false, &InvalidTagError{Tag: tagMin}
+		}
+
+		outside = value < bound
+	}
+
+	if maxTag != "" {
+		bound, err := parse(maxTag)
+		if err != nil {
+			return false, &InvalidTagError{Tag: tagMax}
+		}
+
+		outside = outside || value > bound
+	}
+
+	return outside, nil
+}
@@ -0,0 +1,282 @@
+package envi
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+
+	"golang.org/x/term"
+)
+
+const (
+	colorRed   = "\033[31m"
+	colorGreen = "\033[32m"
+	colorGrey  = "\033[90m"
+	colorReset = "\033[0m"
+)
+
+// ConfigChange describes a config field whose value differs between two configs.
+// Field is the dot separated path of the field, e.g. "YAMLFile.Shell". The values of fields tagged with
+// sensitive:"true" are masked with ***.
+type ConfigChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// fieldDiff holds the values of a single field of two configs.
+type fieldDiff struct {
+	ConfigChange
+	changed bool
+}
+
+/*
+Diff compares two configs of the same type and returns the fields whose values differ.
+Nested structs and pointers to structs are compared field by field, unexported fields are ignored.
+*/
+func Diff(before, after any) ([]ConfigChange, error) {
+	diffs, err := diffConfigs(before, after)
+	if err != nil {
+		return nil, fmt.Errorf("error while comparing configs: %w", err)
+	}
+
+	return changedFields(diffs), nil
+}
+
+// changedFields returns the changes of all changed fields.
+func changedFields(diffs []fieldDiff) []ConfigChange {
+	changes := make([]ConfigChange, 0)
+
+	for _, diff := range diffs {
+		if diff.changed {
+			changes = append(changes, diff.ConfigChange)
+		}
+	}
+
+	return changes
+}
+
+/*
+PrintDiff writes a unified-diff style report of the two configs to w.
+Changed fields are written as "- Field: old" and "+ Field: new" lines, unchanged fields as context lines.
+
+Values of sensitive fields are masked like by Diff. If w is a terminal, removed values are colored red,
+added values green and context lines grey.
+*/
+func PrintDiff(w io.Writer, before, after any) error {
+	const errMsg = "error while printing config diff: %w"
+
+	diffs, err := diffConfigs(before, after)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	colored := IsTerminal(w)
+
+	colorize := func(color, line string) string {
+		if !colored {
+			return line
+		}
+
+		return color + line + colorReset
+	}
+
+	for _, diff := range diffs {
+		var lines []string
+
+		if diff.changed {
+			lines = []string{
+				colorize(colorRed, fmt.Sprintf("- %s: %s", diff.Field, diff.Old)),
+				colorize(colorGreen, fmt.Sprintf("+ %s: %s", diff.Field, diff.New)),
+			}
+		} else {
+			lines = []string{colorize(colorGrey, fmt.Sprintf("  %s: %s", diff.Field, diff.Old))}
+		}
+
+		for _, line := range lines {
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return fmt.Errorf(errMsg, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// IsTerminal reports whether the writer is a file descriptor connected to a terminal.
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+func diffConfigs(before, after any) ([]fieldDiff, error) {
+	bv := reflect.ValueOf(before)
+	av := reflect.ValueOf(after)
+
+	if !bv.IsValid() || !av.IsValid() || bv.Type() != av.Type() {
+		return nil, &InvalidKindError{
+			FieldName: fmt.Sprintf("%T", after),
+			Expected:  fmt.Sprintf("%T", before),
+			Got:       fmt.Sprintf("%T", after),
+		}
+	}
+
+	bv = reflect.Indirect(bv)
+	av = reflect.Indirect(av)
+
+	if bv.Kind() != reflect.Struct || av.Kind() != reflect.Struct {
+		return nil, &InvalidKindError{
+			FieldName: fmt.Sprintf("%T", before),
+			Expected:  "struct",
+			Got:       bv.Kind().String(),
+		}
+	}
+
+	return appendFieldDiffs(nil, "", bv, av), nil
+}
+
+/*
+Diff compares the values loaded by e and other, e.g. the configs of two environments. The result maps every
+key whose value differs to the values of e and other, see Keys. A key that was not loaded by one of the
+instances has an empty value on that side. Neither instance is modified.
+
+File-backed fields are compared by the path of their file, not by its content.
+*/
+func (e *Envi) Diff(other *Envi) map[string][2]string {
+	values := e.finalValues()
+	otherValues := other.finalValues()
+
+	diff := make(map[string][2]string)
+
+	for key, value := range values {
+		if otherValue := otherValues[key]; value != otherValue {
+			diff[key] = [2]string{value, otherValue}
+		}
+	}
+
+	for key, otherValue := range otherValues {
+		if _, ok := values[key]; !ok && otherValue != "" {
+			diff[key] = [2]string{"", otherValue}
+		}
+	}
+
+	return diff
+}
+
+// finalValues returns the final values of all loaded keys.
+func (e *Envi) finalValues() map[string]string {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	values := make(map[string]string, len(e.explanations))
+	for key, explanation := range e.explanations {
+		values[key] = explanation.FinalValue
+	}
+
+	return values
+}
+
+/*
+appendFieldDiffs appends the diffs of all exported fields of before and after. Nested structs and pointers to
+structs are compared field by field. If one of the pointers is nil, the struct is compared as a whole and masked
+if it contains sensitive fields.
+*/
+func appendFieldDiffs(diffs []fieldDiff, prefix string, before, after reflect.Value) []fieldDiff {
+	t := before.Type()
+
+	for i := range t.NumField() {
+		if !t.Field(i).IsExported() {
+			continue
+		}
+
+		name := prefix + t.Field(i).Name
+		bf := before.Field(i)
+		af := after.Field(i)
+
+		sensitive := boolTag(t.Field(i), tagSensitive)
+
+		if !sensitive && isFileStruct(bf.Type()) {
+			diffs = appendFieldDiffs(diffs, name+".", bf, af)
+
+			continue
+		}
+
+		if !sensitive && bf.Kind() == reflect.Pointer && isFileStruct(bf.Type().Elem()) && !bf.IsNil() && !af.IsNil() {
+			diffs = appendFieldDiffs(diffs, name+".", bf.Elem(), af.Elem())
+
+			continue
+		}
+
+		oldValue := formatValue(bf)
+		newValue := formatValue(af)
+
+		diff := fieldDiff{
+			ConfigChange: ConfigChange{
+				Field: name,
+				Old:   oldValue,
+				New:   newValue,
+			},
+			changed: oldValue != newValue,
+		}
+
+		// changes of sensitive fields are reported without revealing the values
+		if sensitive || hasSensitiveFields(bf.Type(), make(map[reflect.Type]bool)) {
+			diff.Old, diff.New = maskedValue, maskedValue
+		}
+
+		diffs = append(diffs, diff)
+	}
+
+	return diffs
+}
+
+// hasSensitiveFields reports whether t contains a field tagged with sensitive:"true", seen guards against recursive types.
+func hasSensitiveFields(t reflect.Type, seen map[reflect.Type]bool) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		return hasSensitiveFields(t.Elem(), seen)
+	case reflect.Map:
+		return hasSensitiveFields(t.Key(), seen) || hasSensitiveFields(t.Elem(), seen)
+	case reflect.Struct:
+		if seen[t] {
+			return false
+		}
+
+		seen[t] = true
+
+		for i := range t.NumField() {
+			if boolTag(t.Field(i), tagSensitive) || hasSensitiveFields(t.Field(i).Type, seen) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// formatValue returns the string representation of a field value, using its String method if available.
+func formatValue(v reflect.Value) string {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "<nil>"
+		}
+
+		v = v.Elem()
+	}
+
+	if stringer, ok := v.Interface().(fmt.Stringer); ok {
+		return stringer.String()
+	}
+
+	// types like net.IPNet implement fmt.Stringer on the pointer receiver
+	ptr := reflect.New(v.Type())
+	ptr.Elem().Set(v)
+
+	if stringer, ok := ptr.Interface().(fmt.Stringer); ok {
+		return stringer.String()
+	}
+
+	return fmt.Sprint(v.Interface())
+}
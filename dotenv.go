@@ -0,0 +1,142 @@
+package envi
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// dotenvUnmarshalFunc returns the unmarshal function for dotenv files that reads the "env" tags with parser.
+func dotenvUnmarshalFunc(parser TagParser) unmarshalFunc {
+	return func(data []byte, v any) error {
+		return unmarshalDotenv(data, v, parser)
+	}
+}
+
+/*
+unmarshalDotenv parses the KEY=VALUE lines of a .env file into the struct v. A line is assigned to the field
+whose "env" tag, as read by parser, matches the key, or to the field with the name of the key if no field has
+a matching tag. The values are parsed according to the "sep", "kvsep" and "layout" tags of the field.
+
+Empty lines and lines starting with # are ignored, as well as an "export " prefix. Values can be quoted with
+single or double quotes, double quoted values support the escape sequences \n, \t, \" and \\ and may span
+multiple lines. Unquoted values end at a " #" comment.
+*/
+func unmarshalDotenv(data []byte, v any, parser TagParser) error {
+	values, err := parseDotenv(data)
+	if err != nil {
+		return &UnmarshalError{Type: "dotenv", Err: err}
+	}
+
+	rv := resolveValuePointer(reflect.ValueOf(v))
+	rt := rv.Type()
+
+	for i := range rt.NumField() {
+		field := rv.Field(i)
+		if !field.CanSet() || !isParsable(resolveTypePointer(field.Type())) {
+			continue
+		}
+
+		key := parser.EnvTag(rt.Field(i))
+		if key == "" {
+			key = rt.Field(i).Name
+		}
+
+		value, ok := values[key]
+		if !ok {
+			continue
+		}
+
+		// pointer fields are only allocated if the file sets a value
+		if err := setValueFormat(resolveValuePointer(field), value, fieldFormat(rt.Field(i))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func parseDotenv(data []byte) (map[string]string, error) {
+	values := make(map[string]string)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", lineNumber)
+		}
+
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		switch {
+		case strings.HasPrefix(value, `"`):
+			// double quoted values may span multiple lines
+			end := closingQuote(value)
+
+			for end == -1 {
+				if !scanner.Scan() {
+					return nil, fmt.Errorf("line %d: unterminated quoted value", lineNumber)
+				}
+
+				lineNumber++
+				value += "\n" + scanner.Text()
+				end = closingQuote(value)
+			}
+
+			value = dotenvEscapes.Replace(value[1:end])
+		case strings.HasPrefix(value, "'"):
+			end := strings.Index(value[1:], "'")
+			if end == -1 {
+				return nil, fmt.Errorf("line %d: unterminated quoted value", lineNumber)
+			}
+
+			value = value[1 : end+1]
+		default:
+			if i := strings.Index(value, " #"); i != -1 {
+				value = strings.TrimSpace(value[:i])
+			}
+		}
+
+		values[key] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// closingQuote returns the index of the unescaped quote that closes the double quoted value, or -1.
+func closingQuote(value string) int {
+	escaped := false
+
+	for i := 1; i < len(value); i++ {
+		switch {
+		case escaped:
+			escaped = false
+		case value[i] == '\\':
+			escaped = true
+		case value[i] == '"':
+			return i
+		}
+	}
+
+	return -1
+}
+
+var dotenvEscapes = strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\"`, `"`, `\\`, `\`)
@@ -3,16 +3,19 @@ package envi
 import (
 	"cmp"
 	"context"
-	"crypto/md5"
 	"encoding/json"
 	"fmt"
+	"maps"
+	"net/http"
 	"os"
 	"path/filepath"
 	"reflect"
-	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/BurntSushi/toml"
 	"github.com/fsnotify/fsnotify"
 	"gopkg.in/yaml.v3"
 )
@@ -23,11 +26,38 @@ const (
 	tagType     = "type"
 	tagRequired = "required"
 	tagWatch    = "watch"
+
+	tagSensitive   = "sensitive"
+	tagDescription = "description"
+	tagAlias       = "alias"
+	tagSeparator   = "sep"
+	tagKVSeparator = "kvsep"
+	tagLayout      = "layout"
+	tagTransform   = "transform"
+	tagOneOf       = "oneof"
+	tagOneOfCI     = "oneofci"
+	tagMin         = "min"
+	tagMax         = "max"
+	tagPattern     = "pattern"
 )
 
+// maxNestingDepth limits the depth of embedded and nested structs, which protects against circular struct definitions.
+const maxNestingDepth = 10
+
 // unmarshalFunc describes how to unmarshal a file.
 type unmarshalFunc func([]byte, any) error
 
+// unmarshalFuncs maps the supported values of the "type" tag to their unmarshal functions.
+var unmarshalFuncs = map[string]unmarshalFunc{
+	"yaml":   netTextUnmarshalFunc(yaml.Unmarshal),
+	"yml":    netTextUnmarshalFunc(yaml.Unmarshal),
+	"json":   netTextUnmarshalFunc(json.Unmarshal),
+	"text":   unmarshalText,
+	"toml":   netTextUnmarshalFunc(toml.Unmarshal),
+	"dotenv": dotenvUnmarshalFunc(DefaultTagParser{}),
+	"env":    dotenvUnmarshalFunc(DefaultTagParser{}),
+}
+
 // FileWatcher is an interface for watching file changes.
 type FileWatcher interface {
 	OnChange()
@@ -43,8 +73,46 @@ type fileWatcherInstance struct {
 // Envi holds references to all active file watchers.
 type Envi struct {
 	errorChan    chan error
-	fileWatchers map[string]fileWatcherInstance
-	fileHashes   map[string]string
+	closed       chan struct{}
+	closeOnce    sync.Once
+	closeMutex   sync.RWMutex
+	paused       atomic.Bool
+	fileWatchers map[targetKey]fileWatcherInstance
+	hashAlgo     HashAlgo
+	envPrefix    string
+	debounce     time.Duration
+	maxRetries   int
+	retryBackoff time.Duration
+	logger       Logger
+	overrides    map[string]string
+	warmFiles    map[string]warmFile
+	mutex        sync.RWMutex
+
+	ctx            context.Context
+	loadGoroutines int
+	tagParser      TagParser
+	loadOrder      []string
+	options        []Option
+	optionErrs     []error
+
+	vault             *vaultClient
+	vaultHTTPClient   *http.Client
+	vaultPollInterval time.Duration
+
+	hooks      map[uint64]hook
+	nextHookID uint64
+	hookMutex  sync.RWMutex
+
+	origins      map[sourceKey]configOrigins
+	loadedEnv    map[string]loadedEnvVar
+	explanations map[string]KeyExplanation
+
+	ready     chan struct{}
+	readyOnce sync.Once
+
+	loadedFiles  map[targetKey]fileField
+	loadedVaults map[targetKey]vaultField
+	sourceLocks  map[string]*sync.Mutex
 }
 
 // Errors returns an error channel where filewatcher errors are sent to.
@@ -52,20 +120,50 @@ func (e *Envi) Errors() <-chan error {
 	return e.errorChan
 }
 
-// Close closes all file watchers attached to the Envi instance.
+/*
+Close closes all file watchers attached to the Envi instance and the error channel.
+Close can be called multiple times, every call after the first one returns nil.
+*/
 func (e *Envi) Close() error {
+	var err error
+
+	e.closeOnce.Do(func() {
+		err = e.close()
+	})
+
+	return err
+}
+
+// close closes the error channel as soon as no watcher is sending to it anymore and closes all file watchers.
+func (e *Envi) close() error {
 	var errs []error
 
+	close(e.closed)
+
+	e.closeMutex.Lock()
 	close(e.errorChan)
+	e.closeMutex.Unlock()
+
+	e.mutex.RLock()
+	fileWatchers := maps.Clone(e.fileWatchers)
+	e.mutex.RUnlock()
 
-	for filePath, instance := range e.fileWatchers {
+	for key, instance := range fileWatchers {
 		instance.cancel()
 
+		if instance.watcher == nil {
+			continue // vault secrets are polled without a file watcher
+		}
+
 		if err := instance.watcher.Close(); err != nil {
-			errs = append(errs, fmt.Errorf("failed to close watcher for file %s with error: %w", filePath, err))
+			errs = append(errs, fmt.Errorf("failed to close watcher for file %s with error: %w", key.path, err))
 		}
 	}
 
+	if err := e.ctx.Err(); err != nil {
+		errs = append(errs, fmt.Errorf("context was done before closing: %w", err))
+	}
+
 	if len(errs) > 0 {
 		return &CloseError{Errors: errs}
 	}
@@ -74,17 +172,44 @@ func (e *Envi) Close() error {
 }
 
 // New creates a new Envi instance.
-func New() *Envi {
-	return &Envi{
-		errorChan:    make(chan error, 100),
-		fileWatchers: make(map[string]fileWatcherInstance, 0),
-		fileHashes:   make(map[string]string),
+func New(options ...Option) *Envi {
+	e := &Envi{
+		errorChan:         make(chan error, 100),
+		closed:            make(chan struct{}),
+		fileWatchers:      make(map[targetKey]fileWatcherInstance, 0),
+		hashAlgo:          HashMD5,
+		logger:            noopLogger{},
+		overrides:         make(map[string]string),
+		warmFiles:         make(map[string]warmFile),
+		ctx:               context.Background(),
+		loadGoroutines:    1,
+		tagParser:         DefaultTagParser{},
+		options:           options,
+		vaultPollInterval: defaultVaultPollInterval,
+		hooks:             make(map[uint64]hook),
+		origins:           make(map[sourceKey]configOrigins),
+		loadedEnv:         make(map[string]loadedEnvVar),
+		explanations:      make(map[string]KeyExplanation),
+		ready:             make(chan struct{}),
+		loadedFiles:       make(map[targetKey]fileField),
+		loadedVaults:      make(map[targetKey]vaultField),
+		sourceLocks:       make(map[string]*sync.Mutex),
+	}
+
+	for _, option := range options {
+		option(e)
 	}
+
+	return e
 }
 
 /*
 Load loads all config files and environment variables into the input struct.
-Supported types are JSON, YAML and text files, as well as strings.
+Supported types are JSON, YAML, TOML, dotenv and text files, as well as strings, ints, uints, floats, bools, slices, maps with string keys, time.Duration, time.Time, net.IPNet (CIDR notation) and net.HardwareAddr.
+
+Pointer fields, e.g. *string or *bool, are allocated if the environment variable or a default is set,
+otherwise they stay nil. This distinguishes an unset value from the zero value.
+A nil pointer counts as missing for the "required" tag.
 
 If you want to watch a file for changes, the "watch" tag has to be set to true and the underlying struct
 has to implement the envi.FileWatcher interface.
@@ -92,8 +217,19 @@ has to implement the envi.FileWatcher interface.
 While using the "default" tag, the "env" tag can be omitted. If not omitted, the value from the
 environment variable will be used.
 
+When using the vault type, the "env" or "default" tag holds the path of a HashiCorp Vault KV v2 secret
+in the form "<mount>/<path>". The secret data is unmarshalled like a JSON file. Watched vault secrets are
+polled for changes, see WithVaultPollInterval.
+
+When using the dotenv file type, the KEY=VALUE lines of the file are loaded into the fields whose "env" tag
+matches the key.
+
 When using the text file type, envi will try to load the file content into the first string field of that struct.
 
+Struct fields without an "env" or "default" tag are nested structs, their fields are loaded from their own tags
+like the fields of the config. Embedded and nested structs are loaded up to 10 levels deep, a NestingDepthError
+is returned for deeper, e.g. circular, struct definitions.
+
 Example config:
 
 	type Config struct {
@@ -120,21 +256,69 @@ Example config:
 	}
 
 Available tags are:
-  - default: default value (supports file paths for files and standard data types bool, float32, float64, int32, int64, string)
+  - default: default value (supports file paths for files and standard data types bool, float32, float64, ints, uints, string, slices, maps, as well as time.Duration, time.Time, net.IPNet and net.HardwareAddr)
   - env: environment variable name
-  - type: describes the file type (json, yaml, text), defaults to yaml if omitted
-  - required: indicates that the field is required, "Load()" will return an error in this case
+  - type: describes the file type (json, yaml, toml, dotenv or env, text, vault), defaults to yaml if omitted
+  - required: indicates that the field is required, "Load()" will return an error in this case. A default always satisfies it
   - watch: indicates that the file should be watched for changes
+  - sep: separator of the elements of slice fields and the entries of map fields, defaults to ","
+  - kvsep: separator of the key and the value of the entries of map fields, defaults to "="
+  - layout: layout of time.Time fields as accepted by time.Parse, defaults to time.RFC3339
+  - oneof: comma separated list of the allowed values of a string field, oneofci compares them case-insensitively
+  - min, max: minimum and maximum number of characters of a string field, or the minimum and maximum value of a numeric field
+  - pattern: regular expression a string field has to match, anchor it with ^ and $ to match the whole value
+  - alias: comma separated fallback environment variable names, which are read in order if the variable of the env tag is not set
+  - transform: comma separated transforms that are applied to the value from left to right before it is set (trim, upper, lower, trimprefix:<prefix>, trimsuffix:<suffix>)
+  - sensitive: marks the value as secret, the environment variable names of sensitive fields are returned by "SensitiveKeys()" and their values are masked by "Redacted()"
 */
 func (e *Envi) Load(config any) error {
+	return e.LoadCtx(context.Background(), config)
+}
+
+/*
+LoadCtx is like Load, but stops reading files and vault secrets when the context is done.
+This prevents a hanging read, e.g. from a network-mounted filesystem, from blocking the startup indefinitely.
+The error of a cancelled load wraps the error of the context.
+*/
+func (e *Envi) LoadCtx(ctx context.Context, config any) error {
+	start := time.Now()
+
+	err := e.load(ctx, config)
+	if err == nil {
+		e.markReady()
+	}
+
+	e.dropWarmFiles()
+
+	e.emit(EventData{EventType: EventLoad, Duration: time.Since(start), Err: err})
+
+	return err
+}
+
+/*
+MustLoad is like Load but panics if the config cannot be loaded. It is meant for programs that cannot start
+without a valid config. The panic value is the error returned by Load, so it can be inspected with
+errors.As after recovering.
+*/
+func (e *Envi) MustLoad(config any) {
+	if err := e.Load(config); err != nil {
+		panic(err)
+	}
+}
+
+func (e *Envi) load(ctx context.Context, config any) error {
 	const errMsg = "error while getting config: %w"
 
-	err := e.loadConfig(config)
+	if len(e.optionErrs) > 0 {
+		return fmt.Errorf(errMsg, &InvalidOptionError{Errors: e.optionErrs})
+	}
+
+	err := e.loadConfig(ctx, config)
 	if err != nil {
 		return fmt.Errorf(errMsg, err)
 	}
 
-	errs := validate(config)
+	errs := validate(config, e.tagParser)
 	if len(errs) > 0 {
 		return fmt.Errorf(errMsg, &ValidationError{Errors: errs})
 	}
@@ -142,7 +326,7 @@ func (e *Envi) Load(config any) error {
 	return nil
 }
 
-func (e *Envi) loadConfig(config any) error {
+func (e *Envi) loadConfig(ctx context.Context, config any) error {
 	const errMsg = "error while loading config: %w"
 
 	v := reflect.ValueOf(config)
@@ -167,78 +351,292 @@ func (e *Envi) loadConfig(config any) error {
 		})
 	}
 
+	if err := checkPatterns(t, make(map[reflect.Type]bool)); err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	sources := &sourceFields{parser: e.tagParser}
+	order := make([]string, 0)
+
+	err := e.loadFields(v, "", sources, &order, 0)
+
+	e.recordOrigins(v, sources.origins)
+
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	if err := e.loadSources(ctx, sources, order); err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	return nil
+}
+
+// loadFields loads the fields of the struct v. File structs are added to sources and the load order
+// of the fields is appended to order. The prefix is the dot separated path of v within the config,
+// fields without an env tag are recorded by their path, e.g. "Database.Host".
+func (e *Envi) loadFields(v reflect.Value, prefix string, sources *sourceFields, order *[]string, depth int) error {
+	t := v.Type()
+
 	for i := 0; i < v.NumField(); i++ {
 		field := v.Field(i)
 
+		// embedded and nested structs are loaded in place, the fields of embedded structs are promoted
+		// to the embedding struct
+		if isEmbeddedStruct(t.Field(i), e.tagParser) || isNestedStruct(t.Field(i), e.tagParser) {
+			if depth >= maxNestingDepth {
+				return &NestingDepthError{FieldName: t.Field(i).Name, MaxDepth: maxNestingDepth}
+			}
+
+			// nil pointers to unexported embedded types cannot be allocated
+			if nested := resolveValuePointer(field); nested.IsValid() {
+				nestedPrefix := prefix
+				if !t.Field(i).Anonymous {
+					nestedPrefix = prefix + t.Field(i).Name + "."
+				}
+
+				if err := e.loadFields(nested, nestedPrefix, sources, order, depth+1); err != nil {
+					return err
+				}
+			}
+
+			continue
+		}
+
 		// filter out unexported fields (CanSet() is false for unexported fields)
 		if !field.CanSet() {
 			continue
 		}
 
-		field = resolveValuePointer(field)
-
-		defaultTag := getStructTag(t.Field(i), tagDefault)
-		envTag := getStructTag(t.Field(i), tagEnv)
+		defaultTag := e.tagParser.DefaultTag(t.Field(i))
+		envTag := e.envName(e.tagParser.EnvTag(t.Field(i)))
 
 		if envTag == "" && defaultTag == "" {
-			return fmt.Errorf(errMsg, &MissingTagError{Tag: "env or default"})
+			return &MissingTagError{Tag: "env or default"}
 		}
 
-		switch field.Kind() {
-		case reflect.Struct:
-			typeTag := getStructTag(t.Field(i), tagType)
-			watchTag := getStructTag(t.Field(i), tagWatch)
+		primaryEnvTag := envTag
+		envTag = e.aliasedEnvName(envTag, getStructTag(t.Field(i), tagAlias))
 
-			path := cmp.Or(os.Getenv(envTag), defaultTag)
+		fieldPath := prefix + t.Field(i).Name
 
-			var err error
-			path, err = filepath.Abs(path)
-			if err != nil {
-				return fmt.Errorf(errMsg, err)
-			}
+		*order = append(*order, e.fieldSources(fieldPath, envTag, defaultTag)...)
 
-			typeVal := cmp.Or(typeTag, "yaml")
+		e.recordEnv(primaryEnvTag, envTag)
+		e.recordExplanation(fieldPath, envTag, defaultTag)
 
-			unmarshalMap := map[string]unmarshalFunc{
-				"yaml": yaml.Unmarshal,
-				"yml":  yaml.Unmarshal,
-				"json": json.Unmarshal,
-				"text": unmarshalText,
-			}
+		// leave optional pointer fields nil if neither the environment variable nor a default is set
+		if isValuePointer(field) && cmp.Or(e.getEnv(envTag), defaultTag) == "" {
+			continue
+		}
 
-			unmarshalFunc, ok := unmarshalMap[typeVal]
-			if !ok {
-				return fmt.Errorf(errMsg, &InvalidTagError{Tag: "type"})
-			}
+		field = resolveValuePointer(field)
+
+		origin := fieldOrigin{source: e.fieldSource(field, t.Field(i), envTag, defaultTag)}
+		if isFileStruct(field.Type()) {
+			origin.path = cmp.Or(e.getEnv(envTag), defaultTag)
+		}
 
-			_, err = e.loadFile(field, path, unmarshalFunc)
+		sources.recordOrigin(field, origin)
+
+		switch {
+		case isFileStruct(field.Type()):
+			err := sources.add(
+				field,
+				cmp.Or(e.getEnv(envTag), defaultTag),
+				e.tagParser.TypeTag(t.Field(i)),
+				e.tagParser.WatchTag(t.Field(i)),
+			)
 			if err != nil {
-				return fmt.Errorf(errMsg, err)
+				return err
 			}
-
-			if watchTag == "true" {
-				err = e.watchFile(field, path, unmarshalFunc)
-				if err != nil {
-					return fmt.Errorf(errMsg, err)
-				}
+		case isParsable(field.Type()):
+			value, err := transform(cmp.Or(e.getEnv(envTag), defaultTag), getStructTag(t.Field(i), tagTransform))
+			if err != nil {
+				return err
 			}
-		case reflect.String:
-			tagVal := getStructTag(t.Field(i), tagEnv)
 
-			if tagVal == "" && defaultTag == "" {
-				return fmt.Errorf(errMsg, &MissingTagError{Tag: "env or default"})
+			if value == "" && field.Kind() != reflect.String {
+				continue
 			}
 
-			field.SetString(cmp.Or(os.Getenv(tagVal), defaultTag))
+			if err := setValueFormat(field, value, fieldFormat(t.Field(i))); err != nil {
+				return err
+			}
 		default:
-			return fmt.Errorf(errMsg, &InvalidKindError{
-				FieldName: field.Type().Name(),
-				Expected:  "string, struct",
+			return &InvalidKindError{
+				FieldName: t.Field(i).Name,
+				Expected:  "string, int, uint, float, bool, slice, map, time.Time, net.IPNet, net.HardwareAddr, struct",
 				Got:       field.Kind().String(),
-			})
+			}
+		}
+	}
+
+	return nil
+}
+
+// sourceFields collects the file and vault backed fields of a config, which are loaded after all other fields.
+type sourceFields struct {
+	files   []fileField
+	vaults  []vaultField
+	parser  TagParser
+	origins map[sourceKey]fieldOrigin
+}
+
+// add adds a field that is loaded from the file or vault secret at path.
+func (s *sourceFields) add(field reflect.Value, path, typeTag string, watch bool) error {
+	if typeTag == typeVault {
+		s.vaults = append(s.vaults, vaultField{
+			field: field,
+			path:  path,
+			watch: watch,
+			hash:  new(string),
+		})
+
+		return nil
+	}
+
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	unmarshalFunc, ok := unmarshalFuncs[cmp.Or(typeTag, "yaml")]
+	if !ok {
+		return &InvalidTagError{Tag: "type"}
+	}
+
+	// dotenv files are matched to the fields by their "env" tags, which have to be read with the configured parser
+	if typeTag == "dotenv" || typeTag == "env" {
+		unmarshalFunc = dotenvUnmarshalFunc(s.parser)
+	}
+
+	s.files = append(s.files, fileField{
+		field:     field,
+		path:      path,
+		unmarshal: unmarshalFunc,
+		watch:     watch,
+		hash:      new(string),
+	})
+
+	return nil
+}
+
+// loadSources loads the collected files and vault secrets, records the load order and sets up the watchers.
+func (e *Envi) loadSources(ctx context.Context, sources *sourceFields, order []string) error {
+	if err := e.loadFiles(ctx, sources.files); err != nil {
+		return err
+	}
+
+	for _, vault := range sources.vaults {
+		if _, err := e.loadVault(ctx, vault); err != nil {
+			return err
+		}
+	}
+
+	for _, file := range sources.files {
+		order = append(order, sourceFile+file.path)
+	}
+
+	for _, vault := range sources.vaults {
+		order = append(order, sourceVault+vault.path)
+	}
+
+	e.mutex.Lock()
+	e.loadOrder = append(e.loadOrder, order...)
+
+	for _, file := range sources.files {
+		e.loadedFiles[newTargetKey(file.path, file.field)] = file
+	}
+
+	for _, vault := range sources.vaults {
+		e.loadedVaults[newTargetKey(sourceVault+vault.path, vault.field)] = vault
+	}
+	e.mutex.Unlock()
+
+	// watchers are set up after all files are loaded
+	for _, file := range sources.files {
+		if !file.watch {
+			continue
+		}
+
+		if err := e.watchFile(file); err != nil {
+			return err
+		}
+	}
+
+	for _, vault := range sources.vaults {
+		if vault.watch {
+			e.watchVault(vault)
+		}
+	}
+
+	return nil
+}
+
+/*
+fileField describes a file-backed struct field of the config. The hash of the content last loaded into the field
+is shared by all copies of the fileField, so the watcher and Reload skip the unmarshalling if the file is unchanged.
+It is empty until the first load, which therefore always unmarshals.
+*/
+type fileField struct {
+	field     reflect.Value
+	path      string
+	unmarshal unmarshalFunc
+	watch     bool
+	hash      *string
+}
+
+// loadFiles loads the given files. If configured via WithConcurrentLoad, the files are loaded concurrently
+// and all errors are collected into a LoadError. Otherwise loading stops at the first error.
+func (e *Envi) loadFiles(ctx context.Context, files []fileField) error {
+	if e.loadGoroutines <= 1 {
+		for _, file := range files {
+			if _, err := e.loadFile(ctx, file); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	var wg sync.WaitGroup
+
+	errs := make([]error, len(files))
+	jobs := make(chan int)
+
+	for range min(e.loadGoroutines, len(files)) {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range jobs {
+				_, errs[i] = e.loadFile(ctx, files[i])
+			}
+		}()
+	}
+
+	for i := range files {
+		jobs <- i
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	loadErrs := make([]error, 0)
+
+	for _, err := range errs {
+		if err != nil {
+			loadErrs = append(loadErrs, err)
 		}
 	}
 
+	if len(loadErrs) > 0 {
+		return &LoadError{Errors: loadErrs}
+	}
+
 	return nil
 }
 
@@ -265,99 +663,97 @@ func unmarshalText(data []byte, v any) error {
 	return nil
 }
 
-// loadFile loads the file at path, checks if it is different from the already loaded file if exists, and unmarshals into the config value.
-func (e *Envi) loadFile(field reflect.Value, path string, unmarshal unmarshalFunc) (bool, error) {
+// loadFile loads the file, checks if it is different from the content last loaded into the field, and unmarshals it
+// into the field. Reloads of the same file are serialized by the source lock, which guards the hash of the field.
+func (e *Envi) loadFile(ctx context.Context, file fileField) (bool, error) {
 	const errMsg = "error while loading file: %w"
 
-	err := handleDefaults(field)
-	if err != nil {
-		return false, fmt.Errorf(errMsg, err)
-	}
+	path, field := file.path, file.field
 
-	blob, err := os.ReadFile(path)
+	blob, newHash, err := e.readFile(ctx, path)
 	if err != nil {
 		return false, fmt.Errorf(errMsg, err)
 	}
 
-	newHash := fmt.Sprintf("%x", md5.Sum(blob))
-	if oldHash, ok := e.fileHashes[path]; ok && newHash == oldHash {
+	if *file.hash != "" && newHash == *file.hash {
+		e.logf("file %s unchanged, skipping reload", path)
+
 		return false, nil // The file has not changed, do not run trigger
-	} else {
-		e.fileHashes[path] = newHash
 	}
 
-	err = unmarshal(blob, field.Addr().Interface())
+	if *file.hash != "" {
+		e.logf("hash of file %s changed, reloading", path)
+	}
+
+	// defaults are applied only if the file changed, otherwise the loaded values would be reset
+	if err := handleDefaults(field, e.tagParser); err != nil {
+		return false, fmt.Errorf(errMsg, err)
+	}
+
+	err = file.unmarshal(blob, field.Addr().Interface())
 	if err != nil {
 		return false, fmt.Errorf(errMsg, err)
 	}
 
+	// the hash is stored only after a successful load, so a retry of a failed load reads the file again
+	*file.hash = newHash
+
 	return true, nil
 }
 
-func handleDefaults(field reflect.Value) error {
+func handleDefaults(field reflect.Value, parser TagParser) error {
 	const errMsg = "error while handling defaults: %w"
 
 	for i := range field.NumField() {
-		defaultTag := getStructTag(field.Type().Field(i), tagDefault)
-
-		if defaultTag != "" {
-			switch field.Field(i).Kind() {
-			case reflect.Int32:
-				fallthrough
-			case reflect.Int64:
-				parsedInt, err := strconv.ParseInt(defaultTag, 10, 64)
-				if err != nil {
-					return fmt.Errorf(errMsg, &ParsingError{Type: "int", Err: err})
-				}
+		defaultTag := parser.DefaultTag(field.Type().Field(i))
 
-				field.Field(i).SetInt(parsedInt)
-			case reflect.Float32:
-				fallthrough
-			case reflect.Float64:
-				parsedFloat, err := strconv.ParseFloat(defaultTag, 64)
-				if err != nil {
-					return fmt.Errorf(errMsg, &ParsingError{Type: "float", Err: err})
-				}
+		if defaultTag == "" {
+			continue
+		}
 
-				field.Field(i).SetFloat(parsedFloat)
-			case reflect.String:
-				field.Field(i).SetString(defaultTag)
-			case reflect.Bool:
-				b, err := strconv.ParseBool(defaultTag)
-				if err != nil {
-					return fmt.Errorf(errMsg, &ParsingError{Type: "bool", Err: err})
-				}
+		// nil pointers are allocated, so the default can be set on the element
+		value := resolveValuePointer(field.Field(i))
 
-				field.Field(i).SetBool(b)
-			default:
-				return fmt.Errorf(errMsg, &InvalidKindError{
-					FieldName: field.Type().Field(i).Name,
-					Expected:  "string, int, float, bool",
-					Got:       field.Field(i).Kind().String(),
-				})
-			}
+		if !isParsable(value.Type()) {
+			return fmt.Errorf(errMsg, &InvalidKindError{
+				FieldName: field.Type().Field(i).Name,
+				Expected:  "string, int, uint, float, bool, slice, map, time.Time",
+				Got:       value.Kind().String(),
+			})
+		}
+
+		transformed, err := transform(defaultTag, getStructTag(field.Type().Field(i), tagTransform))
+		if err != nil {
+			return fmt.Errorf(errMsg, err)
+		}
+
+		if err := setValueFormat(value, transformed, fieldFormat(field.Type().Field(i))); err != nil {
+			return fmt.Errorf(errMsg, err)
 		}
 	}
 
 	return nil
 }
 
-func (e *Envi) watchFile(field reflect.Value, path string, unmarshal unmarshalFunc) error {
+func (e *Envi) watchFile(file fileField) error {
 	const errMsg = "error while watching file: %w"
 
+	path := file.path
+
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		return fmt.Errorf(errMsg, err)
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(e.ctx)
 
-	e.fileWatchers[path] = fileWatcherInstance{
+	e.replaceWatcher(newTargetKey(path, file.field), fileWatcherInstance{
 		watcher: watcher,
+		ctx:     ctx,
 		cancel:  cancel,
-	}
+	})
 
-	go e.fileWatcher(ctx, watcher, field, path, unmarshal)
+	go e.fileWatcher(ctx, watcher, file)
 
 	err = watcher.Add(filepath.Dir(path)) // needs to be the directory of the file to ensure working on linux systems
 	if err != nil {
@@ -369,43 +765,195 @@ func (e *Envi) watchFile(field reflect.Value, path string, unmarshal unmarshalFu
 	return nil
 }
 
-func validate(e any) []error {
-	v := reflect.ValueOf(e)
-	t := reflect.TypeOf(e)
+// replaceWatcher registers the watcher of a loaded field. A watcher of a previous load of the same field is stopped.
+func (e *Envi) replaceWatcher(key targetKey, instance fileWatcherInstance) {
+	e.mutex.Lock()
+	previous, ok := e.fileWatchers[key]
+	e.fileWatchers[key] = instance
+	e.mutex.Unlock()
 
-	v = resolveValuePointer(v)
-	t = resolveTypePointer(t)
+	if !ok {
+		return
+	}
+
+	previous.cancel()
+
+	if previous.watcher != nil {
+		previous.watcher.Close()
+	}
+}
+
+/*
+isWatchedFile reports whether the event name of the directory watcher refers to the watched file.
+On case-insensitive filesystems the event name holds the name as stored on disk, which may differ in case
+from the path of the "env" or "default" tag, so names that only differ in case are compared by their file info.
+*/
+func isWatchedFile(eventName, filePath string) bool {
+	eventBase, fileBase := filepath.Base(eventName), filepath.Base(filePath)
+
+	if eventBase == fileBase {
+		return true
+	}
+
+	if !strings.EqualFold(eventBase, fileBase) {
+		return false
+	}
+
+	eventInfo, err := os.Stat(eventName)
+	if err != nil {
+		return false
+	}
+
+	fileInfo, err := os.Stat(filePath)
+	if err != nil {
+		return false
+	}
+
+	return os.SameFile(eventInfo, fileInfo)
+}
+
+/*
+Validate checks the "required", "oneof", "min", "max" and "pattern" tags of a config that is already populated,
+e.g. by a test helper, without reading any file or environment variable. Nested and embedded structs are
+validated as well. The config may be a struct or a pointer to one.
+
+As no defaults are applied, a required field holding the zero value is missing even if it has a default.
+The tags are read with the TagParser set by WithTagParser.
+
+All violations are returned together in a ValidationError. An InvalidKindError is returned if config is no struct.
+*/
+func (e *Envi) Validate(config any) error {
+	v := reflect.ValueOf(config)
+	if v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return &InvalidKindError{
+			FieldName: fmt.Sprintf("%T", config),
+			Expected:  "struct",
+			Got:       v.Kind().String(),
+		}
+	}
+
+	if errs := validateStruct(v, e.tagParser, false); len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+
+	return nil
+}
+
+// Validate is like Envi.Validate with the default envi tags.
+func Validate(config any) error {
+	return New().Validate(config)
+}
+
+// validate validates a loaded config, the defaults of its fields are applied already.
+func validate(config any, parser TagParser) []error {
+	return validateStruct(resolveValuePointer(reflect.ValueOf(config)), parser, true)
+}
+
+func validateStruct(v reflect.Value, parser TagParser, defaultsApplied bool) []error {
+	t := v.Type()
 
 	errors := make([]error, 0)
 
 	for i := 0; i < v.NumField(); i++ {
 		field := v.Field(i)
 
+		if isEmbeddedStruct(t.Field(i), parser) || isNestedStruct(t.Field(i), parser) {
+			if nested := reflect.Indirect(field); nested.IsValid() {
+				errors = append(errors, validateStruct(nested, parser, defaultsApplied)...)
+			}
+
+			continue
+		}
+
 		if field.Kind() == reflect.Struct {
-			errs := validate(field.Interface())
+			errs := validateStruct(field, parser, defaultsApplied)
 			if len(errs) > 0 {
 				errors = append(errors, errs...)
 			}
 		}
 
-		required := getStructTag(t.Field(i), tagRequired)
-
-		if required == "true" && field.IsZero() {
-			errors = append(errors, &FieldRequiredError{FieldName: t.Field(i).Name})
-		}
+		errors = append(errors, validateField(t.Field(i), field, parser, defaultsApplied)...)
 	}
 
 	return errors
 }
 
+// validateField returns the violations of the "required" tag and the constraint tags of a single field.
+func validateField(f reflect.StructField, field reflect.Value, parser TagParser, defaultsApplied bool) []error {
+	if isRequiredMissing(f, field, parser, defaultsApplied) {
+		return []error{&FieldRequiredError{FieldName: f.Name}}
+	}
+
+	return checkConstraints(f, field)
+}
+
+/*
+isRequiredMissing reports whether the field is tagged as required but holds the zero value.
+
+Once the defaults are applied, a default always provides a value, even if it is the zero value like "0" or "false",
+so fields with a default are never missing. The values of file structs are checked by the tags of their own fields,
+the required tag of a file struct field does not apply to them.
+*/
+func isRequiredMissing(f reflect.StructField, field reflect.Value, parser TagParser, defaultsApplied bool) bool {
+	if !parser.RequiredTag(f) || isFileStruct(resolveTypePointer(f.Type)) {
+		return false
+	}
+
+	if defaultsApplied && parser.DefaultTag(f) != "" {
+		return false
+	}
+
+	// an empty map holds no entries just like a nil map
+	if field.Kind() == reflect.Map {
+		return field.Len() == 0
+	}
+
+	return field.IsZero()
+}
+
+// isEmbeddedStruct reports whether the field embeds a struct whose fields are loaded in place.
+// Embedded structs with an env or default tag are loaded from a file like named struct fields.
+func isEmbeddedStruct(f reflect.StructField, parser TagParser) bool {
+	return f.Anonymous &&
+		isFileStruct(resolveTypePointer(f.Type)) &&
+		parser.EnvTag(f) == "" &&
+		parser.DefaultTag(f) == ""
+}
+
+/*
+isNestedStruct reports whether the exported named field holds a struct without env or default tag. Its fields
+carry envi tags themselves and are loaded like the fields of the config, e.g. Config.Database.Pool.
+*/
+func isNestedStruct(f reflect.StructField, parser TagParser) bool {
+	return !f.Anonymous &&
+		f.IsExported() &&
+		isFileStruct(resolveTypePointer(f.Type)) &&
+		parser.EnvTag(f) == "" &&
+		parser.DefaultTag(f) == ""
+}
+
+// resolveValuePointer dereferences the given value. Nil pointers are allocated if they can be set.
 func resolveValuePointer(rv reflect.Value) reflect.Value {
 	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() && rv.CanSet() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+
 		rv = resolveValuePointer(rv.Elem())
 	}
 
 	return rv
 }
 
+// isValuePointer reports whether the given value is a pointer to a type that is not loaded from a file.
+func isValuePointer(rv reflect.Value) bool {
+	return rv.Kind() == reflect.Pointer && !isFileStruct(resolveTypePointer(rv.Type()))
+}
+
 func resolveTypePointer(rt reflect.Type) reflect.Type {
 	if rt.Kind() == reflect.Ptr {
 		rt = resolveTypePointer(rt.Elem())
@@ -418,72 +966,164 @@ func getStructTag(f reflect.StructField, tagName string) string {
 	return f.Tag.Get(tagName)
 }
 
-func (e *Envi) fileWatcher(
-	ctx context.Context,
-	watcher *fsnotify.Watcher,
-	field reflect.Value,
-	filePath string,
-	unmarshal func([]byte, any) error,
-) {
+func (e *Envi) fileWatcher(ctx context.Context, watcher *fsnotify.Watcher, file fileField) {
 	const errMsg = "error reloading watched file: %w"
 
+	field, filePath := file.field, file.path
+
 	callback, ok := field.Addr().Interface().(FileWatcher)
 	if !ok {
 		return
 	}
 
-	mutex := new(sync.Mutex)
+	reload := func() {
+		err := e.reloadSource(field, filePath, func() (bool, error) {
+			return e.retry(ctx, func() (bool, error) {
+				return e.loadFile(ctx, file)
+			})
+		})
+		if err != nil {
+			e.watchError(callback, filePath, fmt.Errorf(errMsg, err))
+		}
+	}
+
+	// debounced stays nil until the first event is debounced, receiving from a nil channel blocks forever
+	var (
+		timer     *time.Timer
+		debounced <-chan time.Time
+	)
 
 	for {
 		select {
 		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+
+			watcher.Close() // release the watcher if the context of the Envi instance is done before Close is called
+
+			e.logf("watcher for file %s stopping", filePath)
+
 			return
 		case event, ok := <-watcher.Events:
 			if !ok {
+				e.logf("watcher for file %s stopping", filePath)
+
 				return
 			}
 
 			// ensure we're only watching the file we're interested in
-			if filepath.Base(event.Name) != filepath.Base(filePath) {
+			if !isWatchedFile(event.Name, filePath) {
+				continue
+			}
+
+			if !event.Has(fsnotify.Create) && !event.Has(fsnotify.Write) {
+				continue
+			}
+
+			if e.paused.Load() {
+				e.logf("watchers paused, skipping change of file %s", filePath)
+
 				continue
 			}
 
-			if event.Has(fsnotify.Create) || event.Has(fsnotify.Write) {
-				mutex.Lock()
+			if e.debounce <= 0 {
+				reload()
 
-				callOnChange, err := e.loadFile(field, filePath, unmarshal)
-				if err != nil {
-					wrappedErr := fmt.Errorf(errMsg, err)
-					callback.OnError(wrappedErr)
+				continue
+			}
 
+			// every event restarts the timer, so a burst of events results in a single reload
+			if timer == nil {
+				timer = time.NewTimer(e.debounce)
+			} else {
+				if !timer.Stop() {
 					select {
-					case e.errorChan <- wrappedErr: // send the error to the channel if there's space
+					case <-timer.C:
 					default:
-						// drop the error if the channel is full
 					}
-
-					continue
 				}
 
-				mutex.Unlock()
+				timer.Reset(e.debounce)
+			}
 
-				if callOnChange {
-					callback.OnChange()
-				}
+			debounced = timer.C
+		case <-debounced:
+			debounced = nil
+
+			if !e.paused.Load() {
+				reload()
 			}
 		case err, ok := <-watcher.Errors:
 			if !ok {
+				e.logf("watcher for file %s stopping", filePath)
+
 				return
 			}
 
-			wrappedErr := fmt.Errorf(errMsg, err)
-			callback.OnError(wrappedErr)
-
-			select {
-			case e.errorChan <- wrappedErr: // send the error to the channel if there's space
-			default:
-				// drop the error if the channel is full
+			if e.paused.Load() {
+				continue
 			}
+
+			e.watchError(callback, filePath, fmt.Errorf(errMsg, err))
+		}
+	}
+}
+
+// retry calls load until it succeeds, but at most e.maxRetries times more, waiting e.retryBackoff between the
+// attempts. The error of the last attempt is returned, retrying stops early when ctx is done.
+func (e *Envi) retry(ctx context.Context, load func() (bool, error)) (bool, error) {
+	changed, err := load()
+
+	for attempt := 0; err != nil && attempt < e.maxRetries; attempt++ {
+		select {
+		case <-ctx.Done():
+			return false, err
+		case <-time.After(e.retryBackoff):
+		}
+
+		changed, err = load()
+	}
+
+	return changed, err
+}
+
+// watchError reports an error of a watched file or vault secret to the callback, the hooks and the error channel.
+func (e *Envi) watchError(callback FileWatcher, path string, err error) {
+	callback.OnError(err)
+
+	e.emit(EventData{EventType: EventWatchError, FilePath: path, Err: err})
+
+	e.sendError(err)
+}
+
+/*
+sendError sends the error to the error channel if there's space, otherwise the error is dropped.
+An unbuffered channel blocks until the error is received. Errors of watchers that are still running
+briefly after Close are dropped.
+*/
+func (e *Envi) sendError(err error) {
+	e.closeMutex.RLock()
+	defer e.closeMutex.RUnlock()
+
+	select {
+	case <-e.closed:
+		return
+	default:
+	}
+
+	if cap(e.errorChan) == 0 {
+		select {
+		case e.errorChan <- err:
+		case <-e.closed:
 		}
+
+		return
+	}
+
+	select {
+	case e.errorChan <- err:
+	default:
+		e.logf("error channel full, dropping error: %v", err)
 	}
 }
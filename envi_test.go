@@ -1,14 +1,30 @@
 package envi_test
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
+	"testing/iotest"
 	"time"
 
 	"github.com/Clarilab/envi/v3"
+	"github.com/hashicorp/vault/api"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 // !!! Attention: The tests in this file are not meant to be run in parallel because of the t.Setenv usage !!!
@@ -192,6 +208,30 @@ func Test_Filewatcher(t *testing.T) {
 
 	enviClient := envi.New()
 
+	// the names are recorded by the watchers, so the test does not read the fields while they are reloaded
+	var (
+		namesMutex sync.Mutex
+		names      = make(map[string]string)
+	)
+
+	enviClient.Hook(envi.EventWatchChange, func(data envi.EventData) {
+		namesMutex.Lock()
+		defer namesMutex.Unlock()
+
+		for _, change := range data.Changes {
+			if change.Field == "Name" {
+				names[filepath.Base(data.FilePath)] = change.New
+			}
+		}
+	})
+
+	loadedNames := func() (string, string) {
+		namesMutex.Lock()
+		defer namesMutex.Unlock()
+
+		return names["mighty-config.yaml"], names["other-mighty-config.yaml"]
+	}
+
 	if err := os.WriteFile(
 		"mighty-config.yaml",
 		[]byte(fmt.Sprintf("%s: %s", "PETER", "PAN")),
@@ -247,16 +287,24 @@ func Test_Filewatcher(t *testing.T) {
 		time.Sleep(50 * time.Millisecond)
 	}
 
-	for config.MightyConfig.callbackCounter.Load() < 100 && config.OtherMightyConfig.callbackCounter.Load() < 100 {
-		// wait for the callback
-	}
+	deadline := time.Now().Add(5 * time.Second)
+
+	for config.MightyConfig.callbackCounter.Load() < 100 || config.OtherMightyConfig.callbackCounter.Load() < 100 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 100 callbacks per file but got %d and %d",
+				config.MightyConfig.callbackCounter.Load(), config.OtherMightyConfig.callbackCounter.Load())
+		}
 
-	if config.MightyConfig.Name != "PANUS99" {
-		t.Fatal("expected PANUS99")
+		time.Sleep(10 * time.Millisecond)
 	}
 
-	if config.OtherMightyConfig.Name != "OTHER_PANUS99" {
-		t.Fatal("expected OTHER_PANUS99")
+	// a file may be reloaded once more after its last callback was counted, e.g. after it was truncated
+	for name, otherName := loadedNames(); name != "PANUS99" || otherName != "OTHER_PANUS99"; name, otherName = loadedNames() {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected PANUS99 and OTHER_PANUS99 but got %s and %s", name, otherName)
+		}
+
+		time.Sleep(10 * time.Millisecond)
 	}
 
 	err = enviClient.Close()
@@ -265,6 +313,104 @@ func Test_Filewatcher(t *testing.T) {
 	}
 }
 
+type MightyJSONConfig struct {
+	callbackCounter *atomic.Int32
+	Name            string   `json:"PETER" required:"true"`
+	Tenants         []string `json:"TENANTS"`
+}
+
+func (m MightyJSONConfig) OnChange() {
+	m.callbackCounter.Add(1)
+}
+
+func (m MightyJSONConfig) OnError(err error) {
+	fmt.Println(err)
+}
+
+func Test_Filewatcher_JSON(t *testing.T) {
+	type Config struct {
+		MightyConfig MightyJSONConfig `env:"ENVI_TEST_MIGHTY_JSON_CONFIG" type:"json" watch:"true"`
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "mighty-config.json")
+
+	// files are replaced by a rename, so the watcher never sees a partially written file
+	replaceFile := func(content string) {
+		tmp := filepath.Join(dir, "mighty-config.tmp")
+
+		if err := os.WriteFile(tmp, []byte(content), 0o664); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.Rename(tmp, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	waitForCalls := func(config *Config, calls int32) {
+		deadline := time.Now().Add(2 * time.Second)
+
+		for config.MightyConfig.callbackCounter.Load() < calls && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	replaceFile(`{"PETER": "PAN"}`)
+
+	t.Setenv("ENVI_TEST_MIGHTY_JSON_CONFIG", path)
+
+	e := envi.New()
+	defer e.Close()
+
+	config := Config{
+		MightyConfig: MightyJSONConfig{callbackCounter: new(atomic.Int32)},
+	}
+
+	if err := e.Load(&config); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range 10 {
+		replaceFile(fmt.Sprintf(`{"PETER": "PAN%d", "TENANTS": ["tenant-%d"]}`, i, i))
+
+		waitForCalls(&config, int32(i+1))
+	}
+
+	if calls := config.MightyConfig.callbackCounter.Load(); calls != 10 {
+		t.Fatalf("expected 10 OnChange calls but got %d", calls)
+	}
+
+	if config.MightyConfig.Name != "PAN9" || !reflect.DeepEqual(config.MightyConfig.Tenants, []string{"tenant-9"}) {
+		t.Errorf("expected PAN9 with tenant-9 but got %+v", config.MightyConfig)
+	}
+
+	// rewriting the same content keeps the hash, so no reload is triggered
+	replaceFile(`{"PETER": "PAN9", "TENANTS": ["tenant-9"]}`)
+
+	time.Sleep(200 * time.Millisecond)
+
+	if calls := config.MightyConfig.callbackCounter.Load(); calls != 10 {
+		t.Errorf("expected no OnChange call for unchanged content but got %d calls", calls)
+	}
+
+	replaceFile(`{"PETER": `)
+
+	select {
+	case <-e.Errors():
+	case <-time.After(2 * time.Second):
+		t.Error("expected an error for invalid JSON but got none")
+	}
+
+	replaceFile(`{"PETER": "HOOK"}`)
+
+	waitForCalls(&config, 11)
+
+	if config.MightyConfig.Name != "HOOK" {
+		t.Errorf("expected HOOK after recovering from invalid JSON but got %s", config.MightyConfig.Name)
+	}
+}
+
 func Test_ParseFiles(t *testing.T) {
 	type JSONFile struct {
 		URL    string `json:"URL"`
@@ -282,10 +428,16 @@ func Test_ParseFiles(t *testing.T) {
 		Value string
 	}
 
+	type TOMLFile struct {
+		Name string `toml:"NAME"`
+		Port int    `toml:"PORT"`
+	}
+
 	type Config struct {
 		JsonFile JSONFile `default:"./testdata/valid.json" type:"json"`
 		YamlFile YAMLFile `default:"./testdata/valid.yaml" type:"yaml"`
 		TextFile TextFile `default:"./testdata/valid.txt" type:"text"`
+		TomlFile TOMLFile `default:"./testdata/valid.toml" type:"toml"`
 	}
 
 	var myConfig Config
@@ -310,6 +462,10 @@ func Test_ParseFiles(t *testing.T) {
 		TextFile: TextFile{
 			Value: "valid string",
 		},
+		TomlFile: TOMLFile{
+			Name: "envi",
+			Port: 8080,
+		},
 	}
 
 	if myConfig != expectedConfig {
@@ -378,3 +534,5088 @@ func Test_UnexportedFields(t *testing.T) {
 		})
 	}
 }
+
+func Test_LoadAndValidateSchema(t *testing.T) {
+	type Config struct {
+		Environment string `env:"ENVIRONMENT" json:"environment"`
+		ServiceName string `default:"envi-test" json:"serviceName"`
+	}
+
+	schema := []byte(`{
+		"type": "object",
+		"properties": {
+			"environment": {"enum": ["dev", "prod"]},
+			"serviceName": {"type": "string", "minLength": 3}
+		},
+		"required": ["environment", "serviceName"]
+	}`)
+
+	testCases := map[string]struct {
+		schema         []byte
+		expectedConfig Config
+		envvars        map[string]string
+		expectErr      bool
+		expectedErr    error
+	}{
+		"config matching the schema passes validation": {
+			schema: schema,
+			expectedConfig: Config{
+				Environment: "dev",
+				ServiceName: "envi-test",
+			},
+			envvars: map[string]string{
+				"ENVIRONMENT": "dev",
+			},
+			expectErr:   false,
+			expectedErr: nil,
+		},
+		"config violating the schema returns schema error": {
+			schema: schema,
+			envvars: map[string]string{
+				"ENVIRONMENT": "staging",
+			},
+			expectErr:   true,
+			expectedErr: new(envi.ValidationSchemaError),
+		},
+		"invalid schema returns error": {
+			schema: []byte(`{"type": 42}`),
+			envvars: map[string]string{
+				"ENVIRONMENT": "dev",
+			},
+			expectErr:   true,
+			expectedErr: nil,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			for k, v := range tc.envvars {
+				t.Setenv(k, v)
+			}
+
+			var config Config
+
+			e := envi.New()
+
+			err := e.LoadAndValidateSchema(&config, tc.schema)
+			switch {
+			case err != nil && !tc.expectErr:
+				t.Errorf("expected no error but got %v", err)
+			case err == nil && tc.expectErr:
+				t.Error("expected error but got nil")
+			case err != nil && tc.expectedErr != nil:
+				var schemaErr *envi.ValidationSchemaError
+				if !errors.As(err, &schemaErr) {
+					t.Errorf("expected error %T but got %v", tc.expectedErr, err)
+				}
+			case err == nil:
+				if config != tc.expectedConfig {
+					t.Errorf("expected config %+v but got %+v", tc.expectedConfig, config)
+				}
+			}
+		})
+	}
+}
+
+func Test_NumericPointerFields(t *testing.T) {
+	type EnvConfig struct {
+		Int    *int    `env:"ENVI_TEST_NUMBER"`
+		Int8   *int8   `env:"ENVI_TEST_NUMBER"`
+		Int16  *int16  `env:"ENVI_TEST_NUMBER"`
+		Int32  *int32  `env:"ENVI_TEST_NUMBER"`
+		Int64  *int64  `env:"ENVI_TEST_NUMBER"`
+		Uint   *uint   `env:"ENVI_TEST_NUMBER"`
+		Uint8  *uint8  `env:"ENVI_TEST_NUMBER"`
+		Uint16 *uint16 `env:"ENVI_TEST_NUMBER"`
+		Uint32 *uint32 `env:"ENVI_TEST_NUMBER"`
+		Uint64 *uint64 `env:"ENVI_TEST_NUMBER"`
+	}
+
+	type DefaultConfig struct {
+		Int    *int    `default:"7" env:"ENVI_TEST_NUMBER"`
+		Int8   *int8   `default:"7" env:"ENVI_TEST_NUMBER"`
+		Int16  *int16  `default:"7" env:"ENVI_TEST_NUMBER"`
+		Int32  *int32  `default:"7" env:"ENVI_TEST_NUMBER"`
+		Int64  *int64  `default:"7" env:"ENVI_TEST_NUMBER"`
+		Uint   *uint   `default:"7" env:"ENVI_TEST_NUMBER"`
+		Uint8  *uint8  `default:"7" env:"ENVI_TEST_NUMBER"`
+		Uint16 *uint16 `default:"7" env:"ENVI_TEST_NUMBER"`
+		Uint32 *uint32 `default:"7" env:"ENVI_TEST_NUMBER"`
+		Uint64 *uint64 `default:"7" env:"ENVI_TEST_NUMBER"`
+	}
+
+	type RequiredConfig struct {
+		Int    *int    `env:"ENVI_TEST_NUMBER" required:"true"`
+		Int8   *int8   `env:"ENVI_TEST_NUMBER" required:"true"`
+		Int16  *int16  `env:"ENVI_TEST_NUMBER" required:"true"`
+		Int32  *int32  `env:"ENVI_TEST_NUMBER" required:"true"`
+		Int64  *int64  `env:"ENVI_TEST_NUMBER" required:"true"`
+		Uint   *uint   `env:"ENVI_TEST_NUMBER" required:"true"`
+		Uint8  *uint8  `env:"ENVI_TEST_NUMBER" required:"true"`
+		Uint16 *uint16 `env:"ENVI_TEST_NUMBER" required:"true"`
+		Uint32 *uint32 `env:"ENVI_TEST_NUMBER" required:"true"`
+		Uint64 *uint64 `env:"ENVI_TEST_NUMBER" required:"true"`
+	}
+
+	requiredErrs := make([]error, 0)
+	for _, name := range []string{"Int", "Int8", "Int16", "Int32", "Int64", "Uint", "Uint8", "Uint16", "Uint32", "Uint64"} {
+		requiredErrs = append(requiredErrs, &envi.FieldRequiredError{FieldName: name})
+	}
+
+	testCases := map[string]struct {
+		config        any
+		envvars       map[string]string
+		expectedValue string
+		expectedErr   error
+	}{
+		"pointers stay nil without env var and default": {
+			config:        new(EnvConfig),
+			envvars:       nil,
+			expectedValue: "<nil>",
+			expectedErr:   nil,
+		},
+		"pointers are allocated when env var is set": {
+			config: new(EnvConfig),
+			envvars: map[string]string{
+				"ENVI_TEST_NUMBER": "42",
+			},
+			expectedValue: "42",
+			expectedErr:   nil,
+		},
+		"pointers are allocated when default is set": {
+			config:        new(DefaultConfig),
+			envvars:       nil,
+			expectedValue: "7",
+			expectedErr:   nil,
+		},
+		"env var overwrites default": {
+			config: new(DefaultConfig),
+			envvars: map[string]string{
+				"ENVI_TEST_NUMBER": "42",
+			},
+			expectedValue: "42",
+			expectedErr:   nil,
+		},
+		"required nil pointers fail validation": {
+			config:        new(RequiredConfig),
+			envvars:       nil,
+			expectedValue: "",
+			expectedErr:   &envi.ValidationError{Errors: requiredErrs},
+		},
+		"required pointers pass validation when env var is set": {
+			config: new(RequiredConfig),
+			envvars: map[string]string{
+				"ENVI_TEST_NUMBER": "42",
+			},
+			expectedValue: "42",
+			expectedErr:   nil,
+		},
+		"invalid number returns parsing error": {
+			config: new(EnvConfig),
+			envvars: map[string]string{
+				"ENVI_TEST_NUMBER": "not-a-number",
+			},
+			expectedValue: "",
+			expectedErr:   errors.New("error while loading config: could not parse int: strconv.ParseInt: parsing \"not-a-number\": invalid syntax"),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			for k, v := range tc.envvars {
+				t.Setenv(k, v)
+			}
+
+			e := envi.New()
+
+			err := e.Load(tc.config)
+			switch {
+			case err != nil && tc.expectedErr == nil:
+				t.Errorf("expected no error but got %v", err)
+			case err == nil && tc.expectedErr != nil:
+				t.Errorf("expected error %v but got nil", tc.expectedErr)
+			case err != nil && tc.expectedErr != nil:
+				if errors.Unwrap(err).Error() != tc.expectedErr.Error() {
+					t.Errorf("expected error %v but got %v", tc.expectedErr, err)
+				}
+			case err == nil && tc.expectedErr == nil:
+				v := reflect.ValueOf(tc.config).Elem()
+
+				for i := range v.NumField() {
+					got := "<nil>"
+					if !v.Field(i).IsNil() {
+						got = fmt.Sprint(v.Field(i).Elem().Interface())
+					}
+
+					if got != tc.expectedValue {
+						t.Errorf("expected field %s to be %s but got %s", v.Type().Field(i).Name, tc.expectedValue, got)
+					}
+				}
+			}
+		})
+	}
+}
+
+func Test_EnvOverride(t *testing.T) {
+	type Config struct {
+		DBHost      string `default:"localhost" env:"DB_HOST"`
+		DBPort      int    `default:"5432" env:"DB_PORT"`
+		ServiceName string `default:"envi-test" env:"SERVICE_NAME"`
+	}
+
+	testCases := map[string]struct {
+		overrides      map[string]string
+		clear          []string
+		clearAll       bool
+		envvars        map[string]string
+		expectedConfig Config
+	}{
+		"override takes precedence over default": {
+			overrides: map[string]string{
+				"DB_HOST": "test-container",
+			},
+			expectedConfig: Config{
+				DBHost:      "test-container",
+				DBPort:      5432,
+				ServiceName: "envi-test",
+			},
+		},
+		"override takes precedence over env var": {
+			overrides: map[string]string{
+				"DB_PORT": "15432",
+			},
+			envvars: map[string]string{
+				"DB_HOST": "db",
+				"DB_PORT": "6543",
+			},
+			expectedConfig: Config{
+				DBHost:      "db",
+				DBPort:      15432,
+				ServiceName: "envi-test",
+			},
+		},
+		"cleared override falls back to env var": {
+			overrides: map[string]string{
+				"DB_HOST":      "test-container",
+				"SERVICE_NAME": "override-service",
+			},
+			clear: []string{"DB_HOST"},
+			envvars: map[string]string{
+				"DB_HOST": "db",
+			},
+			expectedConfig: Config{
+				DBHost:      "db",
+				DBPort:      5432,
+				ServiceName: "override-service",
+			},
+		},
+		"clearing all overrides falls back to defaults": {
+			overrides: map[string]string{
+				"DB_HOST":      "test-container",
+				"SERVICE_NAME": "override-service",
+			},
+			clearAll: true,
+			expectedConfig: Config{
+				DBHost:      "localhost",
+				DBPort:      5432,
+				ServiceName: "envi-test",
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			for k, v := range tc.envvars {
+				t.Setenv(k, v)
+			}
+
+			e := envi.New()
+
+			for k, v := range tc.overrides {
+				e.SetEnvOverride(k, v)
+			}
+
+			for _, k := range tc.clear {
+				e.ClearOverride(k)
+			}
+
+			if tc.clearAll {
+				e.ClearAllOverrides()
+			}
+
+			var config Config
+
+			if err := e.Load(&config); err != nil {
+				t.Fatal(err)
+			}
+
+			if config != tc.expectedConfig {
+				t.Errorf("expected config %+v but got %+v", tc.expectedConfig, config)
+			}
+
+			for k := range tc.overrides {
+				if _, ok := tc.envvars[k]; !ok && os.Getenv(k) != "" {
+					t.Errorf("expected override %s not to be written to the environment", k)
+				}
+			}
+		})
+	}
+}
+
+func Test_LoadFromReader(t *testing.T) {
+	type Config struct {
+		Host  string   `default:"localhost" env:"ENVI_TEST_READER_HOST"`
+		Port  int      `default:"5432" env:"ENVI_TEST_READER_PORT"`
+		Hosts []string `env:"ENVI_TEST_READER_HOSTS"`
+	}
+
+	testCases := map[string]struct {
+		reader         io.Reader
+		format         string
+		expectedConfig Config
+		expectedErr    error
+	}{
+		"json": {
+			reader:         strings.NewReader(`{"ENVI_TEST_READER_HOST": "db", "ENVI_TEST_READER_PORT": 6543, "ENVI_TEST_READER_HOSTS": ["a", "b"]}`),
+			format:         "json",
+			expectedConfig: Config{Host: "db", Port: 6543, Hosts: []string{"a", "b"}},
+		},
+		"yaml": {
+			reader:         strings.NewReader("ENVI_TEST_READER_HOST: db\nENVI_TEST_READER_HOSTS: [a, b]\n"),
+			format:         "yaml",
+			expectedConfig: Config{Host: "db", Port: 5432, Hosts: []string{"a", "b"}},
+		},
+		"toml": {
+			reader:         strings.NewReader("ENVI_TEST_READER_PORT = 6543\n"),
+			format:         "toml",
+			expectedConfig: Config{Host: "localhost", Port: 6543},
+		},
+		"text": {
+			reader:         strings.NewReader("ENVI_TEST_READER_HOST=db\nENVI_TEST_READER_HOSTS=a,b\n"),
+			format:         "text",
+			expectedConfig: Config{Host: "db", Port: 5432, Hosts: []string{"a", "b"}},
+		},
+		"empty reader": {
+			reader:         strings.NewReader(""),
+			format:         "json",
+			expectedConfig: Config{Host: "localhost", Port: 5432},
+		},
+		"invalid format": {
+			reader:      strings.NewReader("ENVI_TEST_READER_HOST=db"),
+			format:      "xml",
+			expectedErr: errors.New(`unsupported format "xml", expected json, yaml, toml or text`),
+		},
+		"read error": {
+			reader:      iotest.ErrReader(errors.New("connection reset")),
+			format:      "json",
+			expectedErr: errors.New("connection reset"),
+		},
+		"nested object": {
+			reader:      strings.NewReader(`{"ENVI_TEST_READER_HOST": {"name": "db"}}`),
+			format:      "json",
+			expectedErr: errors.New("could not unmarshal json: key ENVI_TEST_READER_HOST: nested objects are not supported"),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			e := envi.New()
+
+			err := e.LoadFromReader(tc.reader, tc.format)
+
+			switch {
+			case err != nil && tc.expectedErr == nil:
+				t.Fatalf("expected no error but got %v", err)
+			case err == nil && tc.expectedErr != nil:
+				t.Fatalf("expected error %v but got nil", tc.expectedErr)
+			case err != nil:
+				if errors.Unwrap(err).Error() != tc.expectedErr.Error() {
+					t.Fatalf("expected error %v but got %v", tc.expectedErr, errors.Unwrap(err))
+				}
+
+				return
+			}
+
+			var config Config
+
+			if err := e.Load(&config); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(config, tc.expectedConfig) {
+				t.Errorf("expected config %+v but got %+v", tc.expectedConfig, config)
+			}
+		})
+	}
+}
+func Test_NetFields(t *testing.T) {
+	type YAMLFile struct {
+		Shell   string           `yaml:"SHELL"`
+		Network net.IPNet        `default:"192.168.0.0/16" yaml:"NETWORK"`
+		MAC     net.HardwareAddr `default:"00:00:5e:00:53:02" yaml:"MAC"`
+	}
+
+	type Config struct {
+		Network  net.IPNet        `default:"10.0.0.0/8" env:"ENVI_TEST_CIDR"`
+		MAC      net.HardwareAddr `env:"ENVI_TEST_MAC" required:"true"`
+		YAMLFile YAMLFile         `default:"./testdata/valid.yaml"`
+	}
+
+	testCases := map[string]struct {
+		envvars         map[string]string
+		expectedNetwork string
+		expectedMAC     string
+		expectedErr     error
+	}{
+		"values are parsed from defaults and env vars": {
+			envvars: map[string]string{
+				"ENVI_TEST_MAC": "00:00:5e:00:53:01",
+			},
+			expectedNetwork: "10.0.0.0/8",
+			expectedMAC:     "00:00:5e:00:53:01",
+			expectedErr:     nil,
+		},
+		"env var overwrites default": {
+			envvars: map[string]string{
+				"ENVI_TEST_CIDR": "172.16.0.0/12",
+				"ENVI_TEST_MAC":  "00:00:5e:00:53:01",
+			},
+			expectedNetwork: "172.16.0.0/12",
+			expectedMAC:     "00:00:5e:00:53:01",
+			expectedErr:     nil,
+		},
+		"missing required mac address fails validation": {
+			envvars: nil,
+			expectedErr: &envi.ValidationError{
+				[]error{&envi.FieldRequiredError{
+					FieldName: "MAC",
+				}},
+			},
+		},
+		"invalid cidr returns parsing error": {
+			envvars: map[string]string{
+				"ENVI_TEST_CIDR": "10.0.0.0",
+				"ENVI_TEST_MAC":  "00:00:5e:00:53:01",
+			},
+			expectedErr: errors.New("error while loading config: could not parse net.IPNet: invalid CIDR address: 10.0.0.0"),
+		},
+		"invalid mac address returns parsing error": {
+			envvars: map[string]string{
+				"ENVI_TEST_MAC": "not-a-mac",
+			},
+			expectedErr: errors.New("error while loading config: could not parse net.HardwareAddr: address not-a-mac: invalid MAC address"),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			for k, v := range tc.envvars {
+				t.Setenv(k, v)
+			}
+
+			var config Config
+
+			e := envi.New()
+
+			err := e.Load(&config)
+			switch {
+			case err != nil && tc.expectedErr == nil:
+				t.Errorf("expected no error but got %v", err)
+			case err == nil && tc.expectedErr != nil:
+				t.Errorf("expected error %v but got nil", tc.expectedErr)
+			case err != nil && tc.expectedErr != nil:
+				if errors.Unwrap(err).Error() != tc.expectedErr.Error() {
+					t.Errorf("expected error %v but got %v", tc.expectedErr, err)
+				}
+			case err == nil && tc.expectedErr == nil:
+				if config.Network.String() != tc.expectedNetwork {
+					t.Errorf("expected network %s but got %s", tc.expectedNetwork, config.Network.String())
+				}
+
+				if config.MAC.String() != tc.expectedMAC {
+					t.Errorf("expected mac %s but got %s", tc.expectedMAC, config.MAC.String())
+				}
+
+				if config.YAMLFile.Shell != "csh" {
+					t.Errorf("expected shell csh but got %s", config.YAMLFile.Shell)
+				}
+
+				if config.YAMLFile.Network.String() != "192.168.0.0/16" {
+					t.Errorf("expected nested network 192.168.0.0/16 but got %s", config.YAMLFile.Network.String())
+				}
+
+				if config.YAMLFile.MAC.String() != "00:00:5e:00:53:02" {
+					t.Errorf("expected nested mac 00:00:5e:00:53:02 but got %s", config.YAMLFile.MAC.String())
+				}
+			}
+		})
+	}
+
+	t.Run("values are decoded from files", func(t *testing.T) {
+		type NetworkFile struct {
+			Network  net.IPNet        `json:"cidr" yaml:"cidr"`
+			MAC      net.HardwareAddr `json:"mac" yaml:"mac"`
+			Fallback net.IPNet        `default:"192.168.0.0/16" json:"fallback" yaml:"fallback"`
+			Routes   []*net.IPNet     `json:"routes" yaml:"routes"`
+		}
+
+		type YAMLConfig struct {
+			File NetworkFile `default:"./testdata/network.yaml"`
+		}
+
+		type JSONConfig struct {
+			File NetworkFile `default:"./testdata/network.json" type:"json"`
+		}
+
+		var (
+			yamlConfig YAMLConfig
+			jsonConfig JSONConfig
+		)
+
+		for name, tc := range map[string]struct {
+			config any
+			file   *NetworkFile
+		}{
+			"yaml": {config: &yamlConfig, file: &yamlConfig.File},
+			"json": {config: &jsonConfig, file: &jsonConfig.File},
+		} {
+			if err := envi.New().Load(tc.config); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+
+			if tc.file.Network.String() != "10.0.0.0/8" {
+				t.Errorf("%s: expected network 10.0.0.0/8 but got %s", name, tc.file.Network.String())
+			}
+
+			if tc.file.MAC.String() != "00:00:5e:00:53:03" {
+				t.Errorf("%s: expected mac 00:00:5e:00:53:03 but got %s", name, tc.file.MAC.String())
+			}
+
+			if tc.file.Fallback.String() != "192.168.0.0/16" {
+				t.Errorf("%s: expected fallback 192.168.0.0/16 but got %s", name, tc.file.Fallback.String())
+			}
+
+			if len(tc.file.Routes) != 1 || tc.file.Routes[0].String() != "172.16.0.0/12" {
+				t.Errorf("%s: expected routes [172.16.0.0/12] but got %v", name, tc.file.Routes)
+			}
+		}
+	})
+
+	t.Run("invalid cidr in file returns parsing error", func(t *testing.T) {
+		type NetworkFile struct {
+			Network net.IPNet `yaml:"cidr"`
+		}
+
+		type FileConfig struct {
+			File NetworkFile `env:"ENVI_TEST_NETWORK_FILE"`
+		}
+
+		path := filepath.Join(t.TempDir(), "network.yaml")
+
+		if err := os.WriteFile(path, []byte(`cidr: "10.0.0.0"`), 0o664); err != nil {
+			t.Fatal(err)
+		}
+
+		t.Setenv("ENVI_TEST_NETWORK_FILE", path)
+
+		var config FileConfig
+
+		var parsingErr *envi.ParsingError
+		if err := envi.New().Load(&config); !errors.As(err, &parsingErr) {
+			t.Errorf("expected parsing error but got %v", err)
+		}
+	})
+}
+
+func Test_WarmUp(t *testing.T) {
+	type JSONFile struct {
+		URL string `json:"URL"`
+	}
+
+	type YAMLFile struct {
+		Shell string `yaml:"SHELL"`
+	}
+
+	type Config struct {
+		JsonFile JSONFile `default:"./testdata/valid.json" type:"json"`
+		YamlFile YAMLFile `default:"./testdata/valid.yaml" type:"yaml"`
+	}
+
+	expectedConfig := Config{
+		JsonFile: JSONFile{URL: "http://foobar.de"},
+		YamlFile: YAMLFile{Shell: "csh"},
+	}
+
+	testCases := map[string]struct {
+		paths          []string
+		expectedErrLen int
+	}{
+		"warm up of all files": {
+			paths:          []string{"./testdata/valid.json", "./testdata/valid.yaml"},
+			expectedErrLen: 0,
+		},
+		"warm up continues after unreadable file": {
+			paths:          []string{"./testdata/valid.json", "./testdata/missing.yaml", "./testdata/valid.yaml"},
+			expectedErrLen: 1,
+		},
+		"load works without warmed up files": {
+			paths:          nil,
+			expectedErrLen: 0,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			e := envi.New()
+
+			err := e.WarmUp(tc.paths)
+			if tc.expectedErrLen == 0 && err != nil {
+				t.Fatalf("expected no error but got %v", err)
+			}
+
+			if tc.expectedErrLen > 0 {
+				var warmUpErr *envi.WarmUpError
+				if !errors.As(err, &warmUpErr) || len(warmUpErr.Errors) != tc.expectedErrLen {
+					t.Fatalf("expected %d warm up errors but got %v", tc.expectedErrLen, err)
+				}
+
+				if !errors.Is(err, os.ErrNotExist) {
+					t.Errorf("expected a warm up error wrapping %v but got %v", os.ErrNotExist, err)
+				}
+			}
+
+			var config Config
+
+			if err := e.Load(&config); err != nil {
+				t.Fatal(err)
+			}
+
+			if config != expectedConfig {
+				t.Errorf("expected config %+v but got %+v", expectedConfig, config)
+			}
+		})
+	}
+
+	t.Run("file changed after warm up is read again", func(t *testing.T) {
+		type FileConfig struct {
+			File YAMLFile `env:"ENVI_TEST_WARM_UP_FILE"`
+		}
+
+		path := filepath.Join(t.TempDir(), "config.yaml")
+
+		if err := os.WriteFile(path, []byte("SHELL: csh"), 0o664); err != nil {
+			t.Fatal(err)
+		}
+
+		t.Setenv("ENVI_TEST_WARM_UP_FILE", path)
+
+		e := envi.New()
+
+		if err := e.WarmUp([]string{path}); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte("SHELL: bash"), 0o664); err != nil {
+			t.Fatal(err)
+		}
+
+		var config FileConfig
+
+		if err := e.Load(&config); err != nil {
+			t.Fatal(err)
+		}
+
+		if config.File.Shell != "bash" {
+			t.Errorf("expected the changed file to be loaded but got %+v", config.File)
+		}
+	})
+}
+
+func Test_ConcurrentLoad(t *testing.T) {
+	type YAMLFile struct {
+		Name string `yaml:"NAME"`
+	}
+
+	type Config struct {
+		First  YAMLFile `env:"ENVI_TEST_FILE_1"`
+		Second YAMLFile `env:"ENVI_TEST_FILE_2"`
+		Third  YAMLFile `env:"ENVI_TEST_FILE_3"`
+		Fourth YAMLFile `env:"ENVI_TEST_FILE_4"`
+		Fifth  YAMLFile `env:"ENVI_TEST_FILE_5"`
+	}
+
+	dir := t.TempDir()
+
+	for i := 1; i <= 5; i++ {
+		path := fmt.Sprintf("%s/file-%d.yaml", dir, i)
+
+		if err := os.WriteFile(path, []byte(fmt.Sprintf("NAME: file-%d", i)), 0o664); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expectedConfig := Config{
+		First:  YAMLFile{Name: "file-1"},
+		Second: YAMLFile{Name: "file-2"},
+		Third:  YAMLFile{Name: "file-3"},
+		Fourth: YAMLFile{Name: "file-4"},
+		Fifth:  YAMLFile{Name: "file-5"},
+	}
+
+	testCases := map[string]struct {
+		options        []envi.Option
+		missingFiles   []int
+		expectedErrLen int
+	}{
+		"sequential load by default": {
+			options: nil,
+		},
+		"one goroutine loads sequentially": {
+			options: []envi.Option{envi.WithConcurrentLoad(1)},
+		},
+		"multiple goroutines load concurrently": {
+			options: []envi.Option{envi.WithConcurrentLoad(3)},
+		},
+		"non positive value uses number of cpus": {
+			options: []envi.Option{envi.WithConcurrentLoad(0)},
+		},
+		"errors of all files are collected": {
+			options:        []envi.Option{envi.WithConcurrentLoad(4)},
+			missingFiles:   []int{2, 4},
+			expectedErrLen: 2,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			for i := 1; i <= 5; i++ {
+				t.Setenv(fmt.Sprintf("ENVI_TEST_FILE_%d", i), fmt.Sprintf("%s/file-%d.yaml", dir, i))
+			}
+
+			for _, i := range tc.missingFiles {
+				t.Setenv(fmt.Sprintf("ENVI_TEST_FILE_%d", i), fmt.Sprintf("%s/missing-%d.yaml", dir, i))
+			}
+
+			var config Config
+
+			e := envi.New(tc.options...)
+
+			err := e.Load(&config)
+			if tc.expectedErrLen > 0 {
+				var loadErr *envi.LoadError
+				if !errors.As(err, &loadErr) || len(loadErr.Errors) != tc.expectedErrLen {
+					t.Fatalf("expected %d load errors but got %v", tc.expectedErrLen, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if config != expectedConfig {
+				t.Errorf("expected config %+v but got %+v", expectedConfig, config)
+			}
+		})
+	}
+}
+
+func Test_SensitiveKeys(t *testing.T) {
+	type Credentials struct {
+		User     string `env:"DB_USER"`
+		Password string `env:"DB_PASSWORD" sensitive:"true"`
+	}
+
+	type Config struct {
+		APIKey      string      `env:"API_KEY" sensitive:"true"`
+		Token       string      `default:"token" sensitive:"true"`
+		ServiceName string      `env:"SERVICE_NAME" sensitive:"false"`
+		Credentials Credentials `default:"./credentials.yaml"`
+		OtherAPIKey string      `env:"API_KEY" sensitive:"true"`
+	}
+
+	testCases := map[string]struct {
+		config   any
+		expected []string
+	}{
+		"sensitive keys are collected sorted and deduplicated": {
+			config:   &Config{},
+			expected: []string{"API_KEY", "DB_PASSWORD"},
+		},
+		"config value works like pointer": {
+			config:   Config{},
+			expected: []string{"API_KEY", "DB_PASSWORD"},
+		},
+		"struct without nested structs works": {
+			config:   &Credentials{},
+			expected: []string{"DB_PASSWORD"},
+		},
+		"nil config returns empty slice": {
+			config:   nil,
+			expected: []string{},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			keys := envi.SensitiveKeys(tc.config)
+
+			if !reflect.DeepEqual(keys, tc.expected) {
+				t.Errorf("expected keys %v but got %v", tc.expected, keys)
+			}
+		})
+	}
+}
+
+func Test_LoadOrder(t *testing.T) {
+	type YAMLFile struct {
+		Shell string `yaml:"SHELL"`
+	}
+
+	type Config struct {
+		DatabaseURL string   `env:"DATABASE_URL"`
+		DBHost      string   `default:"localhost" env:"DB_HOST"`
+		ServiceName string   `default:"envi-test" env:"SERVICE_NAME"`
+		YAMLFile    YAMLFile `default:"./testdata/valid.yaml"`
+	}
+
+	yamlPath, err := filepath.Abs("./testdata/valid.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("DATABASE_URL", "postgres://db")
+	t.Setenv("DB_HOST", "db")
+
+	e := envi.New()
+	e.SetEnvOverride("SERVICE_NAME", "override-service")
+
+	var config Config
+
+	if err := e.Load(&config); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"env:DATABASE_URL",
+		"env:DB_HOST",
+		"default:DBHost",
+		"override:SERVICE_NAME",
+		"default:ServiceName",
+		"default:YAMLFile",
+		"file:" + yamlPath,
+	}
+
+	order := e.LoadOrder()
+	if !reflect.DeepEqual(order, expected) {
+		t.Errorf("expected load order %v but got %v", expected, order)
+	}
+
+	order[0] = "modified"
+
+	if e.LoadOrder()[0] != expected[0] {
+		t.Error("expected LoadOrder to return a copy")
+	}
+}
+
+func Test_BatchLoad(t *testing.T) {
+	type DBConfig struct {
+		Host string `default:"localhost" env:"DB_HOST"`
+	}
+
+	type ServiceConfig struct {
+		Name string `default:"envi-test" env:"SERVICE_NAME"`
+	}
+
+	type AuthConfig struct {
+		Token string `env:"AUTH_TOKEN" required:"true"`
+	}
+
+	testCases := map[string]struct {
+		atomic          bool
+		envvars         map[string]string
+		expectedDB      DBConfig
+		expectedService ServiceConfig
+		expectedAuth    AuthConfig
+		expectedErrLen  int
+	}{
+		"all configs are loaded": {
+			atomic: false,
+			envvars: map[string]string{
+				"AUTH_TOKEN": "token",
+			},
+			expectedDB:      DBConfig{Host: "localhost"},
+			expectedService: ServiceConfig{Name: "envi-test"},
+			expectedAuth:    AuthConfig{Token: "token"},
+			expectedErrLen:  0,
+		},
+		"successful configs stay loaded when another config fails": {
+			atomic:          false,
+			envvars:         nil,
+			expectedDB:      DBConfig{Host: "localhost"},
+			expectedService: ServiceConfig{Name: "envi-test"},
+			expectedAuth:    AuthConfig{},
+			expectedErrLen:  1,
+		},
+		"atomic load loads all configs": {
+			atomic: true,
+			envvars: map[string]string{
+				"AUTH_TOKEN": "token",
+			},
+			expectedDB:      DBConfig{Host: "localhost"},
+			expectedService: ServiceConfig{Name: "envi-test"},
+			expectedAuth:    AuthConfig{Token: "token"},
+			expectedErrLen:  0,
+		},
+		"atomic load changes no config when one config fails": {
+			atomic:          true,
+			envvars:         nil,
+			expectedDB:      DBConfig{Host: "unchanged"},
+			expectedService: ServiceConfig{Name: "unchanged"},
+			expectedAuth:    AuthConfig{},
+			expectedErrLen:  1,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			for k, v := range tc.envvars {
+				t.Setenv(k, v)
+			}
+
+			db := DBConfig{Host: "unchanged"}
+			service := ServiceConfig{Name: "unchanged"}
+
+			var auth AuthConfig
+
+			e := envi.New()
+
+			var err error
+			if tc.atomic {
+				err = e.BatchLoadAtomic(&db, &service, &auth)
+			} else {
+				err = e.BatchLoad(&db, &service, &auth)
+			}
+
+			if tc.expectedErrLen > 0 {
+				var batchErr *envi.BatchLoadError
+				if !errors.As(err, &batchErr) || len(batchErr.Errors) != tc.expectedErrLen {
+					t.Errorf("expected %d batch errors but got %v", tc.expectedErrLen, err)
+				}
+
+				var validationErr *envi.ValidationError
+				if !errors.As(err, &validationErr) {
+					t.Errorf("expected a validation error in %v", err)
+				}
+			} else if err != nil {
+				t.Errorf("expected no error but got %v", err)
+			}
+
+			if db != tc.expectedDB || service != tc.expectedService || auth != tc.expectedAuth {
+				t.Errorf("expected configs %+v %+v %+v but got %+v %+v %+v",
+					tc.expectedDB, tc.expectedService, tc.expectedAuth, db, service, auth)
+			}
+		})
+	}
+}
+
+func Test_LoadNewConfigs(t *testing.T) {
+	type YAMLFile struct {
+		Shell string `yaml:"SHELL"`
+	}
+
+	type Config struct {
+		File YAMLFile `default:"./testdata/valid.yaml"`
+	}
+
+	e := envi.New()
+
+	// the memory of a collected config may be reused by the next one, which must be loaded nevertheless
+	for i := range 200 {
+		config := new(Config)
+
+		if err := e.Load(config); err != nil {
+			t.Fatal(err)
+		}
+
+		if config.File.Shell != "csh" {
+			t.Fatalf("expected load %d to unmarshal the file but got %+v", i, config.File)
+		}
+
+		runtime.GC()
+	}
+}
+
+func Test_BatchLoadSharedState(t *testing.T) {
+	type YAMLFile struct {
+		Shell string `yaml:"SHELL"`
+	}
+
+	type FileConfig struct {
+		File *YAMLFile `default:"./testdata/valid.yaml"`
+		Port *int      `env:"ENVI_TEST_BATCH_PORT"`
+	}
+
+	type AuthConfig struct {
+		Token string `env:"ENVI_TEST_BATCH_TOKEN" required:"true"`
+	}
+
+	t.Run("failed atomic load leaves pointer fields untouched", func(t *testing.T) {
+		t.Setenv("ENVI_TEST_BATCH_PORT", "9")
+
+		config := FileConfig{File: &YAMLFile{Shell: "bash"}, Port: ptr(8080)}
+
+		var auth AuthConfig
+
+		if err := envi.New().BatchLoadAtomic(&config, &auth); err == nil {
+			t.Fatal("expected an error but got nil")
+		}
+
+		if config.File.Shell != "bash" || *config.Port != 8080 {
+			t.Errorf("expected unchanged config but got file %+v and port %d", *config.File, *config.Port)
+		}
+	})
+
+	t.Run("configs loaded from the same file", func(t *testing.T) {
+		var first, second FileConfig
+
+		if err := envi.New().BatchLoad(&first, &second); err != nil {
+			t.Fatal(err)
+		}
+
+		if first.File.Shell != "csh" || second.File.Shell != "csh" {
+			t.Errorf("expected both configs to be loaded but got %+v and %+v", *first.File, *second.File)
+		}
+	})
+
+	t.Run("configs loaded from the same file are reloaded", func(t *testing.T) {
+		type ReloadConfig struct {
+			File ReloadFile `env:"ENVI_TEST_BATCH_SHARED_FILE"`
+		}
+
+		path := filepath.Join(t.TempDir(), "shared.yaml")
+
+		if err := os.WriteFile(path, []byte("NAME: one"), 0o664); err != nil {
+			t.Fatal(err)
+		}
+
+		t.Setenv("ENVI_TEST_BATCH_SHARED_FILE", path)
+
+		e := envi.New()
+
+		first := ReloadConfig{File: ReloadFile{callbackCounter: new(atomic.Int32)}}
+		second := ReloadConfig{File: ReloadFile{callbackCounter: new(atomic.Int32)}}
+
+		if err := e.BatchLoad(&first, &second); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte("NAME: two"), 0o664); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := e.Reload(); err != nil {
+			t.Fatal(err)
+		}
+
+		if first.File.Name != "two" || second.File.Name != "two" {
+			t.Errorf("expected both configs to be reloaded but got %s and %s", first.File.Name, second.File.Name)
+		}
+
+		if err := os.Remove(path); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := e.Reload(); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("expected a reload error wrapping %v but got %v", os.ErrNotExist, err)
+		}
+	})
+
+	t.Run("watchers of configs loaded from the same file are closed", func(t *testing.T) {
+		type WatchConfig struct {
+			File ReloadFile `env:"ENVI_TEST_BATCH_SHARED_FILE" watch:"true"`
+		}
+
+		path := filepath.Join(t.TempDir(), "shared.yaml")
+
+		if err := os.WriteFile(path, []byte("NAME: one"), 0o664); err != nil {
+			t.Fatal(err)
+		}
+
+		t.Setenv("ENVI_TEST_BATCH_SHARED_FILE", path)
+
+		logger := new(recordingLogger)
+
+		e := envi.New(envi.WithLogger(logger))
+
+		first := WatchConfig{File: ReloadFile{callbackCounter: new(atomic.Int32)}}
+		second := WatchConfig{File: ReloadFile{callbackCounter: new(atomic.Int32)}}
+
+		if err := e.BatchLoad(&first, &second); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := e.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		stopping := fmt.Sprintf("envi: watcher for file %s stopping", path)
+
+		for deadline := time.Now().Add(2 * time.Second); logger.count(stopping) < 2; {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected both watchers to stop but got %q", logger.messages)
+			}
+
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+}
+
+func Test_Diff(t *testing.T) {
+	type YAMLFile struct {
+		Shell string `yaml:"SHELL"`
+		Pager string `yaml:"PAGER"`
+	}
+
+	type Config struct {
+		ServiceName string
+		Port        *int
+		Password    string `sensitive:"true"`
+		YAMLFile    YAMLFile
+	}
+
+	port := 8080
+
+	before := Config{
+		ServiceName: "envi-test",
+		Port:        nil,
+		Password:    "peter",
+		YAMLFile:    YAMLFile{Shell: "csh", Pager: "more"},
+	}
+
+	after := Config{
+		ServiceName: "envi-test",
+		Port:        &port,
+		Password:    "pan",
+		YAMLFile:    YAMLFile{Shell: "bash", Pager: "more"},
+	}
+
+	changes, err := envi.Diff(&before, &after)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedChanges := []envi.ConfigChange{
+		{Field: "Port", Old: "<nil>", New: "8080"},
+		{Field: "Password", Old: "***", New: "***"},
+		{Field: "YAMLFile.Shell", Old: "csh", New: "bash"},
+	}
+
+	if !reflect.DeepEqual(changes, expectedChanges) {
+		t.Errorf("expected changes %+v but got %+v", expectedChanges, changes)
+	}
+
+	if _, err := envi.Diff(&before, &YAMLFile{}); err == nil {
+		t.Error("expected error for configs of different types")
+	}
+
+	var buf bytes.Buffer
+
+	if err := envi.PrintDiff(&buf, before, after); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedOutput := "  ServiceName: envi-test\n" +
+		"- Port: <nil>\n" +
+		"+ Port: 8080\n" +
+		"- Password: ***\n" +
+		"+ Password: ***\n" +
+		"- YAMLFile.Shell: csh\n" +
+		"+ YAMLFile.Shell: bash\n" +
+		"  YAMLFile.Pager: more\n"
+
+	if buf.String() != expectedOutput {
+		t.Errorf("expected output\n%s\nbut got\n%s", expectedOutput, buf.String())
+	}
+
+	if envi.IsTerminal(&buf) {
+		t.Error("expected buffer not to be a terminal")
+	}
+}
+
+func Test_DiffSensitiveNestedFields(t *testing.T) {
+	type DB struct {
+		User     string
+		Password string `sensitive:"true"`
+	}
+
+	type Config struct {
+		DB      *DB
+		Replica DB
+		Admin   DB `sensitive:"true"`
+	}
+
+	testCases := map[string]struct {
+		before          Config
+		after           Config
+		expectedChanges []envi.ConfigChange
+	}{
+		"pointer structs are compared field by field": {
+			before: Config{DB: &DB{User: "peter", Password: "old"}},
+			after:  Config{DB: &DB{User: "pan", Password: "new"}},
+			expectedChanges: []envi.ConfigChange{
+				{Field: "DB.User", Old: "peter", New: "pan"},
+				{Field: "DB.Password", Old: "***", New: "***"},
+			},
+		},
+		"nil pointer struct with sensitive fields is masked": {
+			before: Config{DB: nil},
+			after:  Config{DB: &DB{User: "pan", Password: "new"}},
+			expectedChanges: []envi.ConfigChange{
+				{Field: "DB", Old: "***", New: "***"},
+			},
+		},
+		"nested struct is compared field by field": {
+			before: Config{Replica: DB{User: "peter", Password: "old"}},
+			after:  Config{Replica: DB{User: "peter", Password: "new"}},
+			expectedChanges: []envi.ConfigChange{
+				{Field: "Replica.Password", Old: "***", New: "***"},
+			},
+		},
+		"sensitive nested struct is masked as a whole": {
+			before: Config{Admin: DB{User: "peter", Password: "old"}},
+			after:  Config{Admin: DB{User: "pan", Password: "old"}},
+			expectedChanges: []envi.ConfigChange{
+				{Field: "Admin", Old: "***", New: "***"},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			changes, err := envi.Diff(&tc.before, &tc.after)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(changes, tc.expectedChanges) {
+				t.Errorf("expected changes %+v but got %+v", tc.expectedChanges, changes)
+			}
+		})
+	}
+}
+
+func Test_EnviDiff(t *testing.T) {
+	type YAMLFile struct {
+		Shell string `yaml:"SHELL"`
+	}
+
+	type Config struct {
+		Host     string   `default:"localhost" env:"ENVI_TEST_ENVI_DIFF_HOST"`
+		Port     int      `default:"8080" env:"ENVI_TEST_ENVI_DIFF_PORT"`
+		User     string   `env:"ENVI_TEST_ENVI_DIFF_USER"`
+		YAMLFile YAMLFile `default:"./testdata/valid.yaml" env:"ENVI_TEST_ENVI_DIFF_YAML_FILE"`
+	}
+
+	staging := envi.New()
+	staging.SetEnvOverride("ENVI_TEST_ENVI_DIFF_HOST", "staging.example.com")
+	staging.SetEnvOverride("ENVI_TEST_ENVI_DIFF_USER", "peter")
+
+	production := envi.New()
+	production.SetEnvOverride("ENVI_TEST_ENVI_DIFF_HOST", "example.com")
+	production.SetEnvOverride("ENVI_TEST_ENVI_DIFF_YAML_FILE", "./testdata/valid.json")
+
+	var stagingConfig, productionConfig Config
+
+	if err := staging.Load(&stagingConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := production.Load(&productionConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	var timeout string
+
+	err := production.LoadRules([]envi.LoadRule{{Key: "Timeout", Default: "10s", Destination: &timeout}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := map[string]struct {
+		this, other  *envi.Envi
+		expectedDiff map[string][2]string
+	}{
+		"staging and production": {
+			this:  staging,
+			other: production,
+			expectedDiff: map[string][2]string{
+				"ENVI_TEST_ENVI_DIFF_HOST":      {"staging.example.com", "example.com"},
+				"ENVI_TEST_ENVI_DIFF_USER":      {"peter", ""},
+				"ENVI_TEST_ENVI_DIFF_YAML_FILE": {"./testdata/valid.yaml", "./testdata/valid.json"},
+				"Timeout":                       {"", "10s"},
+			},
+		},
+		"production and staging": {
+			this:  production,
+			other: staging,
+			expectedDiff: map[string][2]string{
+				"ENVI_TEST_ENVI_DIFF_HOST":      {"example.com", "staging.example.com"},
+				"ENVI_TEST_ENVI_DIFF_USER":      {"", "peter"},
+				"ENVI_TEST_ENVI_DIFF_YAML_FILE": {"./testdata/valid.json", "./testdata/valid.yaml"},
+				"Timeout":                       {"10s", ""},
+			},
+		},
+		"same instance": {
+			this:         staging,
+			other:        staging,
+			expectedDiff: map[string][2]string{},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			diff := tc.this.Diff(tc.other)
+
+			if !reflect.DeepEqual(diff, tc.expectedDiff) {
+				t.Errorf("expected diff %v but got %v", tc.expectedDiff, diff)
+			}
+		})
+	}
+}
+
+// vaultServer mimics the KV v2 secrets engine of a HashiCorp Vault server.
+type vaultServer struct {
+	mutex   sync.Mutex
+	secrets map[string]string
+}
+
+func (v *vaultServer) setSecret(path, data string) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	v.secrets[path] = data
+}
+
+func (v *vaultServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Vault-Token") != "test-token" {
+		w.WriteHeader(http.StatusForbidden)
+
+		return
+	}
+
+	v.mutex.Lock()
+	data, ok := v.secrets[r.URL.Path]
+	v.mutex.Unlock()
+
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+
+		return
+	}
+
+	fmt.Fprintf(w, `{"data":{"data":%s,"metadata":{"version":1}}}`, data)
+}
+
+type VaultSecret struct {
+	callbackCounter *atomic.Int32
+	Username        string `json:"username"`
+	Password        string `json:"password" sensitive:"true"`
+	Database        string `default:"postgres" json:"database"`
+}
+
+func (v VaultSecret) OnChange() {
+	v.callbackCounter.Add(1)
+}
+
+func (v VaultSecret) OnError(err error) {
+	fmt.Println(err)
+}
+
+func Test_Vault(t *testing.T) {
+	type Config struct {
+		Secret VaultSecret `env:"ENVI_TEST_VAULT_PATH" type:"vault"`
+	}
+
+	server := &vaultServer{secrets: map[string]string{
+		"/v1/secret/data/my-app/database": `{"username":"peter","password":"pan"}`,
+	}}
+
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	tlsServer := httptest.NewTLSServer(server)
+	t.Cleanup(tlsServer.Close)
+
+	apiClient, err := api.NewClient(&api.Config{Address: httpServer.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	apiClient.SetToken("test-token")
+
+	testCases := map[string]struct {
+		path           string
+		options        []envi.Option
+		vaultEnv       map[string]string
+		expectedSecret VaultSecret
+		expectedErr    error
+	}{
+		"secret from vault": {
+			path:           "secret/my-app/database",
+			options:        []envi.Option{envi.WithVault(httpServer.URL, "test-token")},
+			expectedSecret: VaultSecret{Username: "peter", Password: "pan", Database: "postgres"},
+		},
+		"custom http client": {
+			path: "secret/my-app/database",
+			options: []envi.Option{
+				envi.WithVault(tlsServer.URL, "test-token"),
+				envi.WithVaultHTTPClient(tlsServer.Client()),
+			},
+			expectedSecret: VaultSecret{Username: "peter", Password: "pan", Database: "postgres"},
+		},
+		"custom http client with vault configured via environment variables": {
+			path:    "secret/my-app/database",
+			options: []envi.Option{envi.WithVaultHTTPClient(tlsServer.Client())},
+			vaultEnv: map[string]string{
+				"VAULT_ADDR":  tlsServer.URL,
+				"VAULT_TOKEN": "test-token",
+			},
+			expectedSecret: VaultSecret{Username: "peter", Password: "pan", Database: "postgres"},
+		},
+		"vault configured via environment variables": {
+			path: "secret/my-app/database",
+			vaultEnv: map[string]string{
+				"VAULT_ADDR":  httpServer.URL,
+				"VAULT_TOKEN": "test-token",
+			},
+			expectedSecret: VaultSecret{Username: "peter", Password: "pan", Database: "postgres"},
+		},
+		"vault api client": {
+			path:           "secret/my-app/database",
+			options:        []envi.Option{envi.WithVaultClient(apiClient)},
+			expectedSecret: VaultSecret{Username: "peter", Password: "pan", Database: "postgres"},
+		},
+		"missing secret": {
+			path:        "secret/my-app/missing",
+			options:     []envi.Option{envi.WithVault(httpServer.URL, "test-token")},
+			expectedErr: errors.New("error while loading config: error while loading vault secret: could not read vault secret secret/my-app/missing: unexpected status code 404"),
+		},
+		"invalid token": {
+			path:        "secret/my-app/database",
+			options:     []envi.Option{envi.WithVault(httpServer.URL, "wrong-token")},
+			expectedErr: errors.New("error while loading config: error while loading vault secret: could not read vault secret secret/my-app/database: unexpected status code 403"),
+		},
+		"path without mount": {
+			path:        "database",
+			options:     []envi.Option{envi.WithVault(httpServer.URL, "test-token")},
+			expectedErr: errors.New("error while loading config: error while loading vault secret: invalid vault path database, expected <mount>/<path>"),
+		},
+		"vault not configured": {
+			path:        "secret/my-app/database",
+			vaultEnv:    map[string]string{"VAULT_ADDR": "", "VAULT_TOKEN": ""},
+			expectedErr: errors.New("error while loading config: error while loading vault secret: vault is not configured, use WithVault or set VAULT_ADDR and VAULT_TOKEN"),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("ENVI_TEST_VAULT_PATH", tc.path)
+
+			for key, value := range tc.vaultEnv {
+				t.Setenv(key, value)
+			}
+
+			e := envi.New(tc.options...)
+
+			var config Config
+
+			err := e.Load(&config)
+
+			switch {
+			case err == nil && tc.expectedErr != nil:
+				t.Fatalf("expected error %v but got nil", tc.expectedErr)
+			case err != nil && tc.expectedErr == nil:
+				t.Fatalf("expected no error but got %v", err)
+			case err != nil && tc.expectedErr != nil:
+				if errors.Unwrap(err).Error() != tc.expectedErr.Error() {
+					t.Fatalf("expected error %v but got %v", tc.expectedErr, errors.Unwrap(err))
+				}
+
+				return
+			}
+
+			if config.Secret != tc.expectedSecret {
+				t.Errorf("expected secret %+v but got %+v", tc.expectedSecret, config.Secret)
+			}
+		})
+	}
+
+	t.Run("nil vault api client", func(t *testing.T) {
+		var config Config
+
+		err := envi.New(envi.WithVaultClient(nil)).Load(&config)
+		if !errors.Is(err, envi.ErrNilVaultClient) {
+			t.Errorf("expected error %v but got %v", envi.ErrNilVaultClient, err)
+		}
+	})
+}
+
+func Test_VaultWatch(t *testing.T) {
+	type Config struct {
+		Secret VaultSecret `default:"secret/my-app/database" type:"vault" watch:"true"`
+	}
+
+	const secretPath = "/v1/secret/data/my-app/database"
+
+	server := &vaultServer{secrets: map[string]string{
+		secretPath: `{"username":"peter","password":"pan"}`,
+	}}
+
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	e := envi.New(
+		envi.WithVault(httpServer.URL, "test-token"),
+		envi.WithVaultPollInterval(10*time.Millisecond),
+	)
+
+	config := Config{
+		Secret: VaultSecret{callbackCounter: new(atomic.Int32)},
+	}
+
+	if err := e.Load(&config); err != nil {
+		t.Fatal(err)
+	}
+
+	server.setSecret(secretPath, `{"username":"peter","password":"hook"}`)
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for config.Secret.callbackCounter.Load() < 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected OnChange to be called")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Secret.Password != "hook" {
+		t.Errorf("expected password hook but got %s", config.Secret.Password)
+	}
+
+	if config.Secret.callbackCounter.Load() != 1 {
+		t.Errorf("expected OnChange to be called once but got %d calls", config.Secret.callbackCounter.Load())
+	}
+
+	t.Run("invalid poll intervals", func(t *testing.T) {
+		for _, interval := range []time.Duration{0, -time.Second} {
+			config := Config{
+				Secret: VaultSecret{callbackCounter: new(atomic.Int32)},
+			}
+
+			err := envi.New(
+				envi.WithVault(httpServer.URL, "test-token"),
+				envi.WithVaultPollInterval(interval),
+			).Load(&config)
+			if !errors.Is(err, envi.ErrNonPositivePollInterval) {
+				t.Errorf("expected error %v for interval %s but got %v", envi.ErrNonPositivePollInterval, interval, err)
+			}
+		}
+	})
+}
+
+func Test_Hooks(t *testing.T) {
+	type Config struct {
+		Port int `env:"ENVI_TEST_HOOK_PORT"`
+	}
+
+	testCases := map[string]struct {
+		port               string
+		unsubscribe        bool
+		panicking          bool
+		expectedLoadCalls  int32
+		expectedAllCalls   int32
+		expectedErr        bool
+		expectedPanicError bool
+	}{
+		"hooks are called on load": {
+			port:              "8080",
+			expectedLoadCalls: 1,
+			expectedAllCalls:  1,
+		},
+		"hooks receive the load error": {
+			port:              "not-a-port",
+			expectedLoadCalls: 1,
+			expectedAllCalls:  1,
+			expectedErr:       true,
+		},
+		"unsubscribed hooks are not called": {
+			port:              "8080",
+			unsubscribe:       true,
+			expectedLoadCalls: 0,
+			expectedAllCalls:  0,
+		},
+		"panicking hook is recovered": {
+			port:               "8080",
+			panicking:          true,
+			expectedLoadCalls:  1,
+			expectedAllCalls:   1,
+			expectedPanicError: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("ENVI_TEST_HOOK_PORT", tc.port)
+
+			e := envi.New()
+
+			var loadCalls, allCalls atomic.Int32
+
+			var loadErr error
+
+			unsubscribeLoad := e.Hook(envi.EventLoad, func(data envi.EventData) {
+				loadCalls.Add(1)
+				loadErr = data.Err
+			})
+
+			unsubscribeAll := e.HookAll(func(envi.EventData) {
+				allCalls.Add(1)
+
+				if tc.panicking {
+					panic("boom")
+				}
+			})
+
+			e.Hook(envi.EventWatchChange, func(envi.EventData) {
+				t.Error("expected no watch change event")
+			})
+
+			if tc.unsubscribe {
+				unsubscribeLoad()
+				unsubscribeAll()
+				unsubscribeAll()
+			}
+
+			var config Config
+
+			err := e.Load(&config)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error %t but got %v", tc.expectedErr, err)
+			}
+
+			if loadCalls.Load() != tc.expectedLoadCalls {
+				t.Errorf("expected %d load hook calls but got %d", tc.expectedLoadCalls, loadCalls.Load())
+			}
+
+			if allCalls.Load() != tc.expectedAllCalls {
+				t.Errorf("expected %d hook all calls but got %d", tc.expectedAllCalls, allCalls.Load())
+			}
+
+			if tc.expectedLoadCalls > 0 && !errors.Is(loadErr, err) {
+				t.Errorf("expected hook to receive error %v but got %v", err, loadErr)
+			}
+
+			select {
+			case err := <-e.Errors():
+				var panicErr *envi.HookPanicError
+				if !tc.expectedPanicError || !errors.As(err, &panicErr) {
+					t.Errorf("expected no error but got %v", err)
+				}
+			default:
+				if tc.expectedPanicError {
+					t.Error("expected hook panic error")
+				}
+			}
+		})
+	}
+}
+
+func Test_HookWatchChange(t *testing.T) {
+	type Config struct {
+		Secret VaultSecret `default:"secret/my-app/database" type:"vault" watch:"true"`
+	}
+
+	const secretPath = "/v1/secret/data/my-app/database"
+
+	server := &vaultServer{secrets: map[string]string{
+		secretPath: `{"username":"peter","password":"pan"}`,
+	}}
+
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	e := envi.New(
+		envi.WithVault(httpServer.URL, "test-token"),
+		envi.WithVaultPollInterval(10*time.Millisecond),
+	)
+
+	events := make(chan envi.EventData, 1)
+
+	e.Hook(envi.EventWatchChange, func(data envi.EventData) {
+		events <- data
+	})
+
+	config := Config{
+		Secret: VaultSecret{callbackCounter: new(atomic.Int32)},
+	}
+
+	if err := e.Load(&config); err != nil {
+		t.Fatal(err)
+	}
+
+	server.setSecret(secretPath, `{"username":"peter","password":"hook"}`)
+
+	select {
+	case data := <-events:
+		expectedChanges := []envi.ConfigChange{{Field: "Password", Old: "***", New: "***"}}
+
+		if data.FilePath != "vault:secret/my-app/database" {
+			t.Errorf("expected path vault:secret/my-app/database but got %s", data.FilePath)
+		}
+
+		if !reflect.DeepEqual(data.Changes, expectedChanges) {
+			t.Errorf("expected changes %+v but got %+v", expectedChanges, data.Changes)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected watch change event")
+	}
+
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_HookReloadSliceChange(t *testing.T) {
+	type ItemsFile struct {
+		Items []string `json:"items"`
+	}
+
+	type Config struct {
+		File ItemsFile `env:"ENVI_TEST_HOOK_ITEMS_FILE" type:"json"`
+	}
+
+	path := filepath.Join(t.TempDir(), "items.json")
+
+	if err := os.WriteFile(path, []byte(`{"items":["a","b"]}`), 0o664); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("ENVI_TEST_HOOK_ITEMS_FILE", path)
+
+	e := envi.New()
+
+	var changes []envi.ConfigChange
+
+	e.Hook(envi.EventWatchChange, func(data envi.EventData) {
+		changes = data.Changes
+	})
+
+	var config Config
+
+	if err := e.Load(&config); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"items":["x","y"]}`), 0o664); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedChanges := []envi.ConfigChange{{Field: "Items", Old: "[a b]", New: "[x y]"}}
+
+	if !reflect.DeepEqual(changes, expectedChanges) {
+		t.Errorf("expected changes %+v but got %+v", expectedChanges, changes)
+	}
+}
+
+type LintWatchedFile struct {
+	Enabled bool   `required:"true" yaml:"ENABLED"`
+	Name    string `default:"envi" required:"true" yaml:"NAME"`
+}
+
+func (LintWatchedFile) OnChange() {}
+
+func (LintWatchedFile) OnError(error) {}
+
+func Test_Lint(t *testing.T) {
+	type UnwatchableFile struct {
+		Name string `yaml:"NAME"`
+	}
+
+	type ValidConfig struct {
+		Port     int             `default:"8080" env:"PORT"`
+		YAMLFile LintWatchedFile `default:"./config.yaml" watch:"true"`
+	}
+
+	type InvalidConfig struct {
+		Missing  string
+		Type     string          `env:"TYPE" type:"json"`
+		Watch    int             `env:"WATCH" watch:"true"`
+		Unknown  UnwatchableFile `env:"UNKNOWN" type:"xml"`
+		Unwatch  UnwatchableFile `env:"UNWATCH" watch:"true"`
+		Required bool            `default:"true" env:"REQUIRED" required:"true"`
+	}
+
+	type EmbeddingConfig struct {
+		CommonConfig
+		Missing string
+	}
+
+	type NestingConfig struct {
+		Database struct {
+			Host string
+		}
+	}
+
+	type TreeNode struct {
+		Name  string `default:"root" required:"true" yaml:"NAME"`
+		Child *TreeNode
+	}
+
+	type TreeConfig struct {
+		Tree TreeNode `default:"./tree.yaml"`
+	}
+
+	testCases := map[string]struct {
+		config           any
+		expectedWarnings []envi.LintWarning
+	}{
+		"valid config only reports nested findings": {
+			config: &ValidConfig{},
+			expectedWarnings: []envi.LintWarning{
+				{Field: "YAMLFile.Enabled", Severity: envi.LintSeverityWarning, Message: "required on a bool rejects false as a value"},
+				{Field: "YAMLFile.Name", Severity: envi.LintSeverityInfo, Message: "required has no effect because a default is set"},
+			},
+		},
+		"invalid config": {
+			config: InvalidConfig{},
+			expectedWarnings: []envi.LintWarning{
+				{Field: "Missing", Severity: envi.LintSeverityError, Message: "neither env nor default tag is set"},
+				{Field: "Type", Severity: envi.LintSeverityWarning, Message: "type has no effect on fields that are not loaded from a file"},
+				{Field: "Watch", Severity: envi.LintSeverityWarning, Message: "watch has no effect on fields that are not loaded from a file"},
+				{Field: "Unknown", Severity: envi.LintSeverityError, Message: "invalid tag type"},
+				{Field: "Unwatch", Severity: envi.LintSeverityWarning, Message: "watch is set but the struct does not implement FileWatcher"},
+				{Field: "Required", Severity: envi.LintSeverityWarning, Message: "required on a bool rejects false as a value"},
+				{Field: "Required", Severity: envi.LintSeverityInfo, Message: "required has no effect because a default is set"},
+			},
+		},
+		"embedded struct fields are checked in place": {
+			config: &EmbeddingConfig{},
+			expectedWarnings: []envi.LintWarning{
+				{Field: "Missing", Severity: envi.LintSeverityError, Message: "neither env nor default tag is set"},
+			},
+		},
+		"nested struct fields are checked with their path": {
+			config: &NestingConfig{},
+			expectedWarnings: []envi.LintWarning{
+				{Field: "Database.Host", Severity: envi.LintSeverityError, Message: "neither env nor default tag is set"},
+			},
+		},
+		"circular nested struct": {
+			config: &CircularConfig{},
+			expectedWarnings: []envi.LintWarning{
+				{Field: strings.Repeat("Next.", 10) + "Next", Severity: envi.LintSeverityError, Message: "struct exceeds the maximum nesting depth of 10"},
+			},
+		},
+		"self-referential file struct is checked once": {
+			config: &TreeConfig{},
+			expectedWarnings: []envi.LintWarning{
+				{Field: "Tree.Name", Severity: envi.LintSeverityInfo, Message: "required has no effect because a default is set"},
+			},
+		},
+		"no struct": {
+			config: "config",
+			expectedWarnings: []envi.LintWarning{
+				{Field: "string", Severity: envi.LintSeverityError, Message: "expected struct got string"},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			warnings := envi.Lint(tc.config)
+
+			if !reflect.DeepEqual(warnings, tc.expectedWarnings) {
+				t.Errorf("expected warnings %+v but got %+v", tc.expectedWarnings, warnings)
+			}
+		})
+	}
+}
+
+func Test_LintToString(t *testing.T) {
+	type Config struct {
+		Missing string
+		Port    int `env:"PORT"`
+	}
+
+	expected := "error: Missing: neither env nor default tag is set\n"
+
+	if report := envi.LintToString(Config{}); report != expected {
+		t.Errorf("expected report %q but got %q", expected, report)
+	}
+}
+
+func Test_Inspect(t *testing.T) {
+	type YAMLFile struct {
+		Shell string `yaml:"SHELL"`
+		Pager string `required:"true" yaml:"PAGER"`
+	}
+
+	type Config struct {
+		Host     string   `env:"ENVI_TEST_INSPECT_HOST" required:"true"`
+		Port     int      `default:"8080" env:"ENVI_TEST_INSPECT_PORT"`
+		Password string   `env:"ENVI_TEST_INSPECT_PASSWORD" sensitive:"true"`
+		Timeout  *int     `env:"ENVI_TEST_INSPECT_TIMEOUT"`
+		Level    string   `default:"info" env:"ENVI_TEST_INSPECT_LEVEL" oneof:"debug,info"`
+		YAMLFile YAMLFile `default:"./testdata/valid.yaml"`
+	}
+
+	t.Setenv("ENVI_TEST_INSPECT_HOST", "localhost")
+	t.Setenv("ENVI_TEST_INSPECT_PASSWORD", "secret")
+	t.Setenv("ENVI_TEST_INSPECT_LEVEL", "debug")
+
+	e := envi.New()
+	e.SetEnvOverride("ENVI_TEST_INSPECT_HOST", "example.com")
+	// an empty override hides the environment variable, so the default is used
+	e.SetEnvOverride("ENVI_TEST_INSPECT_LEVEL", "")
+
+	var config Config
+
+	if err := e.Load(&config); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedFields := []envi.FieldInfo{
+		{FieldName: "Host", EnvTag: "ENVI_TEST_INSPECT_HOST", ResolvedValue: "example.com", Source: envi.SourceOverride, Required: true, Valid: true},
+		{FieldName: "Port", EnvTag: "ENVI_TEST_INSPECT_PORT", ResolvedValue: "8080", Source: envi.SourceDefault, Valid: true},
+		{FieldName: "Password", EnvTag: "ENVI_TEST_INSPECT_PASSWORD", ResolvedValue: "***", Source: envi.SourceEnv, Sensitive: true, Valid: true},
+		{FieldName: "Timeout", EnvTag: "ENVI_TEST_INSPECT_TIMEOUT", ResolvedValue: "<nil>", Valid: true},
+		{FieldName: "Level", EnvTag: "ENVI_TEST_INSPECT_LEVEL", ResolvedValue: "info", Source: envi.SourceDefault, Valid: true},
+		{FieldName: "YAMLFile", Source: envi.SourceFile, SourcePath: "./testdata/valid.yaml", Valid: true},
+		{FieldName: "YAMLFile.Shell", ResolvedValue: "csh", Source: envi.SourceFile, SourcePath: "./testdata/valid.yaml", Valid: true},
+		{FieldName: "YAMLFile.Pager", ResolvedValue: "more", Source: envi.SourceFile, SourcePath: "./testdata/valid.yaml", Required: true, Valid: true},
+	}
+
+	result := e.Inspect(&config)
+
+	if !reflect.DeepEqual(result.Fields, expectedFields) {
+		t.Errorf("expected fields %+v but got %+v", expectedFields, result.Fields)
+	}
+
+	if order := e.LoadOrder(); slices.Contains(order, "override:ENVI_TEST_INSPECT_LEVEL") {
+		t.Errorf("expected empty override not to be in load order but got %v", order)
+	}
+
+	if explanation := e.ExplainKey("ENVI_TEST_INSPECT_LEVEL"); explanation.FinalValue != "info" {
+		t.Errorf("expected final value info but got %q", explanation.FinalValue)
+	}
+
+	config.Host = ""
+	config.Level = "trace"
+
+	result = e.Inspect(&config)
+
+	if result.Fields[0].Valid {
+		t.Error("expected empty required field to be invalid")
+	}
+
+	if result.Fields[4].Valid {
+		t.Error("expected field violating its oneof tag to be invalid")
+	}
+}
+
+func Test_InspectOrigins(t *testing.T) {
+	type Config struct {
+		Host string `default:"localhost" env:"ENVI_TEST_INSPECT_ORIGINS_HOST"`
+	}
+
+	t.Setenv("ENVI_TEST_INSPECT_ORIGINS_HOST", "example.com")
+
+	e := envi.New()
+
+	var config Config
+
+	if err := e.Load(&config); err != nil {
+		t.Fatal(err)
+	}
+
+	if source := e.Inspect(&config).Fields[0].Source; source != envi.SourceEnv {
+		t.Errorf("expected source %q but got %q", envi.SourceEnv, source)
+	}
+
+	// the origins are scoped to the loaded config, another config of the same type has none
+	if source := e.Inspect(&Config{Host: config.Host}).Fields[0].Source; source != "" {
+		t.Errorf("expected no source for a config that was not loaded but got %q", source)
+	}
+
+	t.Setenv("ENVI_TEST_INSPECT_ORIGINS_HOST", "")
+
+	if err := e.Load(&config); err != nil {
+		t.Fatal(err)
+	}
+
+	if source := e.Inspect(&config).Fields[0].Source; source != envi.SourceDefault {
+		t.Errorf("expected source %q after reload but got %q", envi.SourceDefault, source)
+	}
+}
+
+func Test_CompareWithEnvironment(t *testing.T) {
+	type Config struct {
+		Host    string `env:"ENVI_TEST_DRIFT_HOST"`
+		Port    string `default:"8080" env:"ENVI_TEST_DRIFT_PORT"`
+		User    string `env:"ENVI_TEST_DRIFT_USER"`
+		Stable  string `env:"ENVI_TEST_DRIFT_STABLE"`
+		Default string `default:"foo"`
+	}
+
+	t.Setenv("ENVI_TEST_DRIFT_HOST", "localhost")
+	t.Setenv("ENVI_TEST_DRIFT_PORT", "")
+	t.Setenv("ENVI_TEST_DRIFT_USER", "peter")
+	t.Setenv("ENVI_TEST_DRIFT_STABLE", "stable")
+
+	e := envi.New()
+
+	var config Config
+
+	if err := e.Load(&config); err != nil {
+		t.Fatal(err)
+	}
+
+	if discrepancies := e.CompareWithEnvironment(); len(discrepancies) != 0 {
+		t.Fatalf("expected no discrepancies but got %+v", discrepancies)
+	}
+
+	t.Setenv("ENVI_TEST_DRIFT_HOST", "example.com")
+	t.Setenv("ENVI_TEST_DRIFT_PORT", "9090")
+	t.Setenv("ENVI_TEST_DRIFT_USER", "")
+
+	expected := []envi.Discrepancy{
+		{Key: "ENVI_TEST_DRIFT_HOST", LoadedValue: "localhost", CurrentEnvValue: "example.com", Type: envi.DiscrepancyChanged},
+		{Key: "ENVI_TEST_DRIFT_PORT", LoadedValue: "", CurrentEnvValue: "9090", Type: envi.DiscrepancyAdded},
+		{Key: "ENVI_TEST_DRIFT_USER", LoadedValue: "peter", CurrentEnvValue: "", Type: envi.DiscrepancyRemoved},
+	}
+
+	if discrepancies := e.CompareWithEnvironment(); !reflect.DeepEqual(discrepancies, expected) {
+		t.Errorf("expected discrepancies %+v but got %+v", expected, discrepancies)
+	}
+}
+
+func Test_CompareWithEnvironmentAlias(t *testing.T) {
+	type Config struct {
+		URL string `alias:"ENVI_TEST_DRIFT_ALIAS_DB_URL" env:"ENVI_TEST_DRIFT_ALIAS_URL"`
+	}
+
+	testCases := map[string]struct {
+		envvars               map[string]string
+		expectedDiscrepancies []envi.Discrepancy
+	}{
+		"unchanged alias": {
+			expectedDiscrepancies: []envi.Discrepancy{},
+		},
+		"changed alias": {
+			envvars: map[string]string{"ENVI_TEST_DRIFT_ALIAS_DB_URL": "postgres://changed"},
+			expectedDiscrepancies: []envi.Discrepancy{
+				{
+					Key:             "ENVI_TEST_DRIFT_ALIAS_URL",
+					Source:          "ENVI_TEST_DRIFT_ALIAS_DB_URL",
+					LoadedValue:     "postgres://alias",
+					CurrentEnvValue: "postgres://changed",
+					Type:            envi.DiscrepancyChanged,
+				},
+			},
+		},
+		"primary set after load": {
+			envvars: map[string]string{"ENVI_TEST_DRIFT_ALIAS_URL": "postgres://primary"},
+			expectedDiscrepancies: []envi.Discrepancy{
+				{
+					Key:             "ENVI_TEST_DRIFT_ALIAS_URL",
+					Source:          "ENVI_TEST_DRIFT_ALIAS_DB_URL",
+					LoadedValue:     "postgres://alias",
+					CurrentEnvValue: "postgres://primary",
+					Type:            envi.DiscrepancyChanged,
+				},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("ENVI_TEST_DRIFT_ALIAS_URL", "")
+			t.Setenv("ENVI_TEST_DRIFT_ALIAS_DB_URL", "postgres://alias")
+
+			e := envi.New()
+
+			var config Config
+
+			if err := e.Load(&config); err != nil {
+				t.Fatal(err)
+			}
+
+			for k, v := range tc.envvars {
+				t.Setenv(k, v)
+			}
+
+			if discrepancies := e.CompareWithEnvironment(); !reflect.DeepEqual(discrepancies, tc.expectedDiscrepancies) {
+				t.Errorf("expected discrepancies %+v but got %+v", tc.expectedDiscrepancies, discrepancies)
+			}
+		})
+	}
+}
+
+func Test_LoadRules(t *testing.T) {
+	type JSONFile struct {
+		URL    string `json:"URL"`
+		Editor string `json:"EDITOR" required:"true"`
+	}
+
+	testCases := map[string]struct {
+		rules        func(port *int, host *string, file *JSONFile) []envi.LoadRule
+		expectedPort int
+		expectedHost string
+		expectedFile JSONFile
+		expectedErr  error
+	}{
+		"rules from env and defaults": {
+			rules: func(port *int, host *string, file *JSONFile) []envi.LoadRule {
+				return []envi.LoadRule{
+					{Key: "Port", EnvVar: "ENVI_TEST_RULES_PORT", Default: "8080", Destination: port},
+					{Key: "Host", EnvVar: "ENVI_TEST_RULES_HOST", Required: true, Destination: host},
+					{Key: "File", Default: "./testdata/valid.json", Type: "json", Destination: file},
+				}
+			},
+			expectedPort: 9090,
+			expectedHost: "localhost",
+			expectedFile: JSONFile{URL: "http://foobar.de", Editor: "emacs"},
+		},
+		"missing required value": {
+			rules: func(port *int, host *string, file *JSONFile) []envi.LoadRule {
+				return []envi.LoadRule{
+					{Key: "Host", EnvVar: "ENVI_TEST_RULES_MISSING", Required: true, Destination: host},
+				}
+			},
+			expectedErr: errors.New("field Host is required\n"),
+		},
+		"destination is no pointer": {
+			rules: func(port *int, host *string, file *JSONFile) []envi.LoadRule {
+				return []envi.LoadRule{
+					{Key: "Port", Default: "8080", Destination: 8080},
+				}
+			},
+			expectedErr: errors.New("expected field Port to be kind pointer got int"),
+		},
+		"missing env var and default": {
+			rules: func(port *int, host *string, file *JSONFile) []envi.LoadRule {
+				return []envi.LoadRule{
+					{Key: "Port", Destination: port},
+				}
+			},
+			expectedErr: errors.New("tag env or default not set"),
+		},
+		"invalid file type": {
+			rules: func(port *int, host *string, file *JSONFile) []envi.LoadRule {
+				return []envi.LoadRule{
+					{Key: "File", Default: "./testdata/valid.json", Type: "xml", Destination: file},
+				}
+			},
+			expectedErr: errors.New("invalid tag type"),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("ENVI_TEST_RULES_PORT", "9090")
+			t.Setenv("ENVI_TEST_RULES_HOST", "localhost")
+
+			var (
+				port int
+				host string
+				file JSONFile
+			)
+
+			err := envi.New().LoadRules(tc.rules(&port, &host, &file))
+
+			switch {
+			case err == nil && tc.expectedErr != nil:
+				t.Fatalf("expected error %v but got nil", tc.expectedErr)
+			case err != nil && tc.expectedErr == nil:
+				t.Fatalf("expected no error but got %v", err)
+			case err != nil && tc.expectedErr != nil:
+				if errors.Unwrap(err).Error() != tc.expectedErr.Error() {
+					t.Fatalf("expected error %v but got %v", tc.expectedErr, errors.Unwrap(err))
+				}
+
+				return
+			}
+
+			if port != tc.expectedPort || host != tc.expectedHost || file != tc.expectedFile {
+				t.Errorf("expected %d, %s, %+v but got %d, %s, %+v", tc.expectedPort, tc.expectedHost, tc.expectedFile, port, host, file)
+			}
+		})
+	}
+}
+
+func Test_PrometheusMetrics(t *testing.T) {
+	type YAMLFile struct {
+		Shell string `yaml:"SHELL"`
+	}
+
+	type Config struct {
+		Port int       `env:"ENVI_TEST_METRICS_PORT"`
+		File *YAMLFile `env:"ENVI_TEST_METRICS_FILE"`
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	if err := os.WriteFile(path, []byte("SHELL: csh\n"), 0o664); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("ENVI_TEST_METRICS_FILE", path)
+
+	e := envi.New()
+
+	registry := prometheus.NewRegistry()
+
+	if err := e.RegisterPrometheusMetrics(registry, "envi"); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("ENVI_TEST_METRICS_PORT", "8080")
+
+	var config Config
+
+	if err := e.Load(&config); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte("SHELL: [csh\n"), 0o664); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.Reload(); err == nil {
+		t.Fatal("expected reload error")
+	}
+
+	t.Setenv("ENVI_TEST_METRICS_PORT", "not-a-port")
+
+	if err := e.Load(&config); err == nil {
+		t.Fatal("expected error")
+	}
+
+	expected := `
+# HELP envi_errors_total Number of failed loads and reloads.
+# TYPE envi_errors_total counter
+envi_errors_total 2
+# HELP envi_reloads_total Number of reloads of watched files and vault secrets.
+# TYPE envi_reloads_total counter
+envi_reloads_total 1
+# HELP envi_watched_files Number of watched files and vault secrets.
+# TYPE envi_watched_files gauge
+envi_watched_files 0
+`
+
+	err := testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"envi_errors_total", "envi_reloads_total", "envi_watched_files")
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count := testutil.CollectAndCount(registry, "envi_load_duration_seconds"); count != 1 {
+		t.Errorf("expected load duration histogram but got %d metrics", count)
+	}
+
+	if err := e.RegisterPrometheusMetrics(registry, "envi"); err == nil {
+		t.Error("expected error for already registered metrics")
+	}
+
+	t.Run("failed registration is rolled back", func(t *testing.T) {
+		registry := prometheus.NewRegistry()
+
+		// the errors counter is registered after the reloads counter, so the latter has to be unregistered again
+		registry.MustRegister(prometheus.NewCounter(prometheus.CounterOpts{Name: "envi_errors_total", Help: "taken"}))
+
+		if err := envi.New().RegisterPrometheusMetrics(registry, "envi"); err == nil {
+			t.Fatal("expected error for already registered metrics")
+		}
+
+		if count := testutil.CollectAndCount(registry, "envi_reloads_total"); count != 0 {
+			t.Errorf("expected reloads counter to be unregistered but got %d metrics", count)
+		}
+	})
+}
+
+func Test_ExplainKey(t *testing.T) {
+	type Config struct {
+		Host    string `default:"localhost" env:"ENVI_TEST_EXPLAIN_HOST"`
+		Port    string `default:"8080" env:"ENVI_TEST_EXPLAIN_PORT"`
+		User    string `env:"ENVI_TEST_EXPLAIN_USER"`
+		Timeout string `default:"10s"`
+	}
+
+	t.Setenv("ENVI_TEST_EXPLAIN_HOST", "example.com")
+	t.Setenv("ENVI_TEST_EXPLAIN_PORT", "")
+	t.Setenv("ENVI_TEST_EXPLAIN_USER", "peter")
+
+	e := envi.New()
+	e.SetEnvOverride("ENVI_TEST_EXPLAIN_USER", "paul")
+
+	var config Config
+
+	if err := e.Load(&config); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := map[string]struct {
+		key                 string
+		expectedExplanation envi.KeyExplanation
+	}{
+		"value from environment variable": {
+			key: "ENVI_TEST_EXPLAIN_HOST",
+			expectedExplanation: envi.KeyExplanation{
+				Key:        "ENVI_TEST_EXPLAIN_HOST",
+				FinalValue: "example.com",
+				Steps: []envi.ExplainStep{
+					{Source: "override:ENVI_TEST_EXPLAIN_HOST"},
+					{Source: "env:ENVI_TEST_EXPLAIN_HOST", Value: "example.com", Applied: true},
+					{Source: "default:Host", Value: "localhost"},
+				},
+			},
+		},
+		"value from default": {
+			key: "ENVI_TEST_EXPLAIN_PORT",
+			expectedExplanation: envi.KeyExplanation{
+				Key:        "ENVI_TEST_EXPLAIN_PORT",
+				FinalValue: "8080",
+				Steps: []envi.ExplainStep{
+					{Source: "override:ENVI_TEST_EXPLAIN_PORT"},
+					{Source: "env:ENVI_TEST_EXPLAIN_PORT"},
+					{Source: "default:Port", Value: "8080", Applied: true},
+				},
+			},
+		},
+		"value from override": {
+			key: "ENVI_TEST_EXPLAIN_USER",
+			expectedExplanation: envi.KeyExplanation{
+				Key:        "ENVI_TEST_EXPLAIN_USER",
+				FinalValue: "paul",
+				Steps: []envi.ExplainStep{
+					{Source: "override:ENVI_TEST_EXPLAIN_USER", Value: "paul", Applied: true},
+					{Source: "env:ENVI_TEST_EXPLAIN_USER", Value: "peter"},
+				},
+			},
+		},
+		"field without env tag": {
+			key: "Timeout",
+			expectedExplanation: envi.KeyExplanation{
+				Key:        "Timeout",
+				FinalValue: "10s",
+				Steps: []envi.ExplainStep{
+					{Source: "default:Timeout", Value: "10s", Applied: true},
+				},
+			},
+		},
+		"unknown key": {
+			key:                 "ENVI_TEST_EXPLAIN_UNKNOWN",
+			expectedExplanation: envi.KeyExplanation{Key: "ENVI_TEST_EXPLAIN_UNKNOWN"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			explanation := e.ExplainKey(tc.key)
+
+			if !reflect.DeepEqual(explanation, tc.expectedExplanation) {
+				t.Errorf("expected explanation %+v but got %+v", tc.expectedExplanation, explanation)
+			}
+		})
+	}
+}
+
+func Test_Keys(t *testing.T) {
+	type YAMLFile struct {
+		Shell string `yaml:"SHELL"`
+	}
+
+	type Config struct {
+		Host     string   `default:"localhost" env:"ENVI_TEST_KEYS_HOST"`
+		Timeout  string   `default:"10s"`
+		YAMLFile YAMLFile `default:"./testdata/valid.yaml" env:"ENVI_TEST_KEYS_YAML_FILE"`
+	}
+
+	type HostConfig struct {
+		Host string `default:"localhost"`
+	}
+
+	type NestedConfig struct {
+		DB    HostConfig
+		Cache HostConfig
+	}
+
+	type RulesConfig struct {
+		Port int
+	}
+
+	e := envi.New()
+
+	if keys := e.Keys(); len(keys) != 0 {
+		t.Errorf("expected no keys before Load but got %v", keys)
+	}
+
+	var config Config
+
+	if err := e.Load(&config); err != nil {
+		t.Fatal(err)
+	}
+
+	nested := NestedConfig{}
+
+	if err := e.Load(&nested); err != nil {
+		t.Fatal(err)
+	}
+
+	var rules RulesConfig
+
+	if err := e.LoadRules([]envi.LoadRule{{Key: "Port", Default: "8080", Destination: &rules.Port}}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"Cache.Host", "DB.Host", "ENVI_TEST_KEYS_HOST", "ENVI_TEST_KEYS_YAML_FILE", "Port", "Timeout"}
+
+	if keys := e.Keys(); !reflect.DeepEqual(keys, expected) {
+		t.Errorf("expected keys %v but got %v", expected, keys)
+	}
+}
+
+func Test_WaitForReady(t *testing.T) {
+	type Config struct {
+		Port int `env:"ENVI_TEST_READY_PORT"`
+	}
+
+	t.Setenv("ENVI_TEST_READY_PORT", "not-a-port")
+
+	e := envi.New()
+
+	var config Config
+
+	if err := e.Load(&config); err == nil {
+		t.Fatal("expected error")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := e.WaitForReady(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded after failed load but got %v", err)
+	}
+
+	var wg sync.WaitGroup
+
+	errs := make(chan error, 3)
+
+	for range 3 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			errs <- e.WaitForReady(context.Background())
+		}()
+	}
+
+	t.Setenv("ENVI_TEST_READY_PORT", "8080")
+
+	if err := e.Load(&config); err != nil {
+		t.Fatal(err)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("expected no error but got %v", err)
+		}
+	}
+
+	if err := e.Load(&config); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.WaitForReady(context.Background()); err != nil {
+		t.Errorf("expected no error after repeated load but got %v", err)
+	}
+}
+
+func Test_ToTable(t *testing.T) {
+	type YAMLFile struct {
+		Shell string `yaml:"SHELL"`
+	}
+
+	type Config struct {
+		Port     int      `default:"8080" env:"TABLE_PORT"`
+		Password string   `env:"TABLE_PASSWORD" sensitive:"true"`
+		Timeout  string   `default:"10s"`
+		YAMLFile YAMLFile `default:"./testdata/valid.yaml"`
+	}
+
+	t.Setenv("ENVI_TEST_TABLE_PASSWORD", "secret")
+
+	e := envi.New(envi.WithEnvPrefix("ENVI_TEST_"))
+
+	var config Config
+
+	if err := e.Load(&config); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `+--------------------------+------------+----------------------------+
+| KEY                      | VALUE      | SOURCE                     |
++--------------------------+------------+----------------------------+
+| ENVI_TEST_TABLE_PASSWORD | [REDACTED] | env                        |
+| ENVI_TEST_TABLE_PORT     | 8080       | default                    |
+| Timeout                  | 10s        | default                    |
+| YAMLFile.Shell           | csh        | file:./testdata/valid.yaml |
++--------------------------+------------+----------------------------+
+`
+
+	var buf bytes.Buffer
+
+	if err := e.ToTable(&buf, &config); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != expected {
+		t.Errorf("expected table\n%s\nbut got\n%s", expected, buf.String())
+	}
+}
+
+func Test_DotenvFile(t *testing.T) {
+	type DotenvFile struct {
+		Host        string `env:"DB_HOST"`
+		Port        int    `env:"DB_PORT"`
+		User        string `env:"DB_USER"`
+		Password    string `env:"DB_PASSWORD"`
+		CERTIFICATE string
+		Database    string `default:"postgres" env:"DB_NAME"`
+	}
+
+	testCases := map[string]struct {
+		content        string
+		expectedConfig DotenvFile
+		expectedErr    error
+	}{
+		"valid dotenv file": {
+			content: "",
+			expectedConfig: DotenvFile{
+				Host:        "localhost",
+				Port:        5432,
+				User:        "peter pan",
+				Password:    `p@ss "word"`,
+				CERTIFICATE: "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----",
+				Database:    "postgres",
+			},
+		},
+		"line without value": {
+			content:     "DB_HOST\n",
+			expectedErr: errors.New("error while loading file: could not unmarshal dotenv: line 1: expected KEY=VALUE"),
+		},
+		"unterminated quoted value": {
+			content:     "DB_USER=peter\nDB_PASSWORD=\"secret\n",
+			expectedErr: errors.New("error while loading file: could not unmarshal dotenv: line 2: unterminated quoted value"),
+		},
+		"invalid int": {
+			content:     "DB_PORT=postgres\n",
+			expectedErr: errors.New("error while loading file: could not parse int: strconv.ParseInt: parsing \"postgres\": invalid syntax"),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			type Config struct {
+				Dotenv DotenvFile `env:"ENVI_TEST_DOTENV_FILE" type:"dotenv"`
+			}
+
+			path := "./testdata/valid.env"
+
+			if tc.content != "" {
+				path = filepath.Join(t.TempDir(), ".env")
+
+				if err := os.WriteFile(path, []byte(tc.content), 0o664); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			t.Setenv("ENVI_TEST_DOTENV_FILE", path)
+
+			var config Config
+
+			err := envi.New().Load(&config)
+
+			switch {
+			case err == nil && tc.expectedErr != nil:
+				t.Fatalf("expected error %v but got nil", tc.expectedErr)
+			case err != nil && tc.expectedErr == nil:
+				t.Fatalf("expected no error but got %v", err)
+			case err != nil && tc.expectedErr != nil:
+				if errors.Unwrap(errors.Unwrap(err)).Error() != tc.expectedErr.Error() {
+					t.Fatalf("expected error %v but got %v", tc.expectedErr, errors.Unwrap(errors.Unwrap(err)))
+				}
+
+				return
+			}
+
+			if config.Dotenv != tc.expectedConfig {
+				t.Errorf("expected config %+v but got %+v", tc.expectedConfig, config.Dotenv)
+			}
+		})
+	}
+}
+
+func Test_DotenvFileFormats(t *testing.T) {
+	type DotenvFile struct {
+		Hosts   []string          `env:"HOSTS" sep:";"`
+		Labels  map[string]string `env:"LABELS" kvsep:"="`
+		Since   time.Time         `env:"SINCE" layout:"2006-01-02"`
+		Debug   *bool             `env:"DEBUG"`
+		Timeout *time.Duration    `env:"TIMEOUT"`
+	}
+
+	type Config struct {
+		Dotenv DotenvFile `env:"ENVI_TEST_DOTENV_FILE" type:"dotenv"`
+	}
+
+	path := filepath.Join(t.TempDir(), ".env")
+
+	content := "HOSTS=a;b\nLABELS=team=core,env=prod\nSINCE=2024-05-01\nDEBUG=true\n"
+
+	if err := os.WriteFile(path, []byte(content), 0o664); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("ENVI_TEST_DOTENV_FILE", path)
+
+	var config Config
+
+	if err := envi.New().Load(&config); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := DotenvFile{
+		Hosts:  []string{"a", "b"},
+		Labels: map[string]string{"team": "core", "env": "prod"},
+		Since:  time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		Debug:  ptr(true),
+	}
+
+	if !reflect.DeepEqual(config.Dotenv, expected) {
+		t.Errorf("expected config %+v but got %+v", expected, config.Dotenv)
+	}
+}
+func Test_DurationFields(t *testing.T) {
+	type YAMLFile struct {
+		Shell   string        `yaml:"SHELL"`
+		Timeout time.Duration `default:"30s" yaml:"TIMEOUT"`
+	}
+
+	type Config struct {
+		Interval time.Duration  `env:"ENVI_TEST_DURATION_INTERVAL"`
+		Backoff  *time.Duration `env:"ENVI_TEST_DURATION_BACKOFF"`
+		YAMLFile YAMLFile       `default:"./testdata/valid.yaml"`
+	}
+
+	testCases := map[string]struct {
+		interval         string
+		expectedInterval time.Duration
+		expectedErr      error
+	}{
+		"milliseconds": {
+			interval:         "500ms",
+			expectedInterval: 500 * time.Millisecond,
+		},
+		"minutes and seconds": {
+			interval:         "2m30s",
+			expectedInterval: 2*time.Minute + 30*time.Second,
+		},
+		"zero": {
+			interval:         "0",
+			expectedInterval: 0,
+		},
+		"invalid duration": {
+			interval:    "30 seconds",
+			expectedErr: errors.New(`error while loading config: could not parse time.Duration: time: unknown unit " seconds" in duration "30 seconds"`),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("ENVI_TEST_DURATION_INTERVAL", tc.interval)
+			t.Setenv("ENVI_TEST_DURATION_BACKOFF", tc.interval)
+
+			var config Config
+
+			err := envi.New().Load(&config)
+
+			switch {
+			case err == nil && tc.expectedErr != nil:
+				t.Fatalf("expected error %v but got nil", tc.expectedErr)
+			case err != nil && tc.expectedErr == nil:
+				t.Fatalf("expected no error but got %v", err)
+			case err != nil && tc.expectedErr != nil:
+				if errors.Unwrap(err).Error() != tc.expectedErr.Error() {
+					t.Fatalf("expected error %v but got %v", tc.expectedErr, errors.Unwrap(err))
+				}
+
+				return
+			}
+
+			if config.Interval != tc.expectedInterval {
+				t.Errorf("expected interval %s but got %s", tc.expectedInterval, config.Interval)
+			}
+
+			if config.Backoff == nil || *config.Backoff != tc.expectedInterval {
+				t.Errorf("expected backoff %s but got %v", tc.expectedInterval, config.Backoff)
+			}
+
+			if config.YAMLFile.Timeout != 30*time.Second {
+				t.Errorf("expected default timeout 30s but got %s", config.YAMLFile.Timeout)
+			}
+		})
+	}
+}
+
+func Test_SliceFields(t *testing.T) {
+	type YAMLFile struct {
+		Shell    string   `yaml:"SHELL"`
+		Backends []string `default:"a;b" sep:";" yaml:"BACKENDS"`
+	}
+
+	type Config struct {
+		Origins  []string `env:"ENVI_TEST_SLICE_ORIGINS" required:"true"`
+		Hosts    []string `default:"localhost;127.0.0.1" env:"ENVI_TEST_SLICE_HOSTS" sep:";"`
+		Ports    []int    `env:"ENVI_TEST_SLICE_PORTS"`
+		YAMLFile YAMLFile `default:"./testdata/valid.yaml"`
+	}
+
+	testCases := map[string]struct {
+		env            map[string]string
+		expectedConfig Config
+		expectedErr    error
+	}{
+		"comma separated values": {
+			env: map[string]string{
+				"ENVI_TEST_SLICE_ORIGINS": "https://a.com, https://b.com",
+				"ENVI_TEST_SLICE_PORTS":   "80,443",
+			},
+			expectedConfig: Config{
+				Origins:  []string{"https://a.com", "https://b.com"},
+				Hosts:    []string{"localhost", "127.0.0.1"},
+				Ports:    []int{80, 443},
+				YAMLFile: YAMLFile{Shell: "csh", Backends: []string{"a", "b"}},
+			},
+		},
+		"custom separator": {
+			env: map[string]string{
+				"ENVI_TEST_SLICE_ORIGINS": "https://a.com",
+				"ENVI_TEST_SLICE_HOSTS":   "a.com;b.com",
+			},
+			expectedConfig: Config{
+				Origins:  []string{"https://a.com"},
+				Hosts:    []string{"a.com", "b.com"},
+				YAMLFile: YAMLFile{Shell: "csh", Backends: []string{"a", "b"}},
+			},
+		},
+		"missing required slice": {
+			env:         map[string]string{},
+			expectedErr: errors.New("field Origins is required\n"),
+		},
+		"invalid element": {
+			env: map[string]string{
+				"ENVI_TEST_SLICE_ORIGINS": "https://a.com",
+				"ENVI_TEST_SLICE_PORTS":   "80,https",
+			},
+			expectedErr: errors.New("error while loading config: could not parse int: strconv.ParseInt: parsing \"https\": invalid syntax"),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("ENVI_TEST_SLICE_ORIGINS", "")
+			t.Setenv("ENVI_TEST_SLICE_HOSTS", "")
+			t.Setenv("ENVI_TEST_SLICE_PORTS", "")
+
+			for key, value := range tc.env {
+				t.Setenv(key, value)
+			}
+
+			var config Config
+
+			err := envi.New().Load(&config)
+
+			switch {
+			case err == nil && tc.expectedErr != nil:
+				t.Fatalf("expected error %v but got nil", tc.expectedErr)
+			case err != nil && tc.expectedErr == nil:
+				t.Fatalf("expected no error but got %v", err)
+			case err != nil && tc.expectedErr != nil:
+				if errors.Unwrap(err).Error() != tc.expectedErr.Error() {
+					t.Fatalf("expected error %v but got %v", tc.expectedErr, errors.Unwrap(err))
+				}
+
+				return
+			}
+
+			if !reflect.DeepEqual(config, tc.expectedConfig) {
+				t.Errorf("expected config %+v but got %+v", tc.expectedConfig, config)
+			}
+		})
+	}
+}
+
+func Test_IntegerDefaults(t *testing.T) {
+	type IntegerFile struct {
+		Shell  string `yaml:"SHELL"`
+		Int    int    `default:"8080"`
+		Int8   int8   `default:"-8"`
+		Uint   uint   `default:"42"`
+		Uint32 uint32 `default:"4294967295"`
+		Uint64 uint64 `default:"18446744073709551615"`
+	}
+
+	type Config struct {
+		Port     uint16      `default:"443" env:"ENVI_TEST_INTEGER_PORT"`
+		Workers  int         `default:"4" env:"ENVI_TEST_INTEGER_WORKERS"`
+		YAMLFile IntegerFile `default:"./testdata/valid.yaml"`
+	}
+
+	testCases := map[string]struct {
+		port           string
+		expectedConfig Config
+		expectedErr    error
+	}{
+		"defaults for all integer kinds": {
+			expectedConfig: Config{
+				Port:    443,
+				Workers: 4,
+				YAMLFile: IntegerFile{
+					Shell:  "csh",
+					Int:    8080,
+					Int8:   -8,
+					Uint:   42,
+					Uint32: 4294967295,
+					Uint64: 18446744073709551615,
+				},
+			},
+		},
+		"uint from env": {
+			port: "8443",
+			expectedConfig: Config{
+				Port:    8443,
+				Workers: 4,
+				YAMLFile: IntegerFile{
+					Shell:  "csh",
+					Int:    8080,
+					Int8:   -8,
+					Uint:   42,
+					Uint32: 4294967295,
+					Uint64: 18446744073709551615,
+				},
+			},
+		},
+		"negative uint": {
+			port:        "-1",
+			expectedErr: errors.New("error while loading config: could not parse uint: strconv.ParseUint: parsing \"-1\": invalid syntax"),
+		},
+		"uint overflow": {
+			port:        "65536",
+			expectedErr: errors.New("error while loading config: could not parse uint: strconv.ParseUint: parsing \"65536\": value out of range"),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("ENVI_TEST_INTEGER_PORT", tc.port)
+
+			var config Config
+
+			err := envi.New().Load(&config)
+
+			switch {
+			case err == nil && tc.expectedErr != nil:
+				t.Fatalf("expected error %v but got nil", tc.expectedErr)
+			case err != nil && tc.expectedErr == nil:
+				t.Fatalf("expected no error but got %v", err)
+			case err != nil && tc.expectedErr != nil:
+				if errors.Unwrap(err).Error() != tc.expectedErr.Error() {
+					t.Fatalf("expected error %v but got %v", tc.expectedErr, errors.Unwrap(err))
+				}
+
+				return
+			}
+
+			if config != tc.expectedConfig {
+				t.Errorf("expected config %+v but got %+v", tc.expectedConfig, config)
+			}
+		})
+	}
+}
+
+type ReloadFile struct {
+	callbackCounter *atomic.Int32
+	Name            string `yaml:"NAME"`
+	Region          string `default:"eu-central-1" yaml:"REGION"`
+}
+
+func (r ReloadFile) OnChange() {
+	r.callbackCounter.Add(1)
+}
+
+func (r ReloadFile) OnError(err error) {
+	fmt.Println(err)
+}
+
+func Test_Reload(t *testing.T) {
+	type Config struct {
+		File ReloadFile `env:"ENVI_TEST_RELOAD_FILE"`
+	}
+
+	path := filepath.Join(t.TempDir(), "reload.yaml")
+
+	if err := os.WriteFile(path, []byte("NAME: peter"), 0o664); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("ENVI_TEST_RELOAD_FILE", path)
+
+	e := envi.New()
+
+	config := Config{
+		File: ReloadFile{callbackCounter: new(atomic.Int32)},
+	}
+
+	if err := e.Load(&config); err != nil {
+		t.Fatal(err)
+	}
+
+	// an unchanged file must not reset the values to their defaults
+	config.File.Region = "us-east-1"
+
+	if err := e.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	if config.File.Region != "us-east-1" || config.File.callbackCounter.Load() != 0 {
+		t.Fatalf("expected unchanged config but got %+v with %d calls", config.File, config.File.callbackCounter.Load())
+	}
+
+	if err := os.WriteFile(path, []byte("NAME: paul"), 0o664); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	if config.File.Name != "paul" || config.File.Region != "eu-central-1" {
+		t.Errorf("expected reloaded config but got %+v", config.File)
+	}
+
+	if config.File.callbackCounter.Load() != 1 {
+		t.Errorf("expected OnChange to be called once but got %d calls", config.File.callbackCounter.Load())
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+
+	var reloadErr *envi.ReloadError
+	if err := e.Reload(); !errors.As(err, &reloadErr) || len(reloadErr.Errors) != 1 {
+		t.Errorf("expected reload error for removed file but got %v", err)
+	}
+}
+
+func Test_WithContext(t *testing.T) {
+	type Config struct {
+		Secret VaultSecret `default:"secret/my-app/database" type:"vault" watch:"true"`
+	}
+
+	const secretPath = "/v1/secret/data/my-app/database"
+
+	server := &vaultServer{secrets: map[string]string{
+		secretPath: `{"username":"peter","password":"pan"}`,
+	}}
+
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	e := envi.New(
+		envi.WithContext(ctx),
+		envi.WithVault(httpServer.URL, "test-token"),
+		envi.WithVaultPollInterval(10*time.Millisecond),
+	)
+
+	config := Config{
+		Secret: VaultSecret{callbackCounter: new(atomic.Int32)},
+	}
+
+	if err := e.Load(&config); err != nil {
+		t.Fatal(err)
+	}
+
+	cancel()
+
+	// give the watcher time to observe the cancellation before the secret changes
+	time.Sleep(50 * time.Millisecond)
+
+	server.setSecret(secretPath, `{"username":"peter","password":"hook"}`)
+
+	time.Sleep(50 * time.Millisecond)
+
+	if config.Secret.callbackCounter.Load() != 0 {
+		t.Errorf("expected no reload after the context was cancelled but got %d calls", config.Secret.callbackCounter.Load())
+	}
+
+	if err := e.Close(); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected close error wrapping context.Canceled but got %v", err)
+	}
+
+	t.Run("nil context", func(t *testing.T) {
+		var config struct{}
+
+		e := envi.New(envi.WithContext(nil))
+
+		if err := e.Load(&config); !errors.Is(err, envi.ErrNilContext) {
+			t.Errorf("expected nil context error but got %v", err)
+		}
+
+		if err := e.Close(); err != nil {
+			t.Errorf("expected no close error but got %v", err)
+		}
+	})
+}
+
+// Test_ConcurrentAccess is meant to be run with the race detector.
+func Test_ConcurrentAccess(t *testing.T) {
+	type YAMLFile struct {
+		Shell string `yaml:"SHELL"`
+	}
+
+	type Config struct {
+		Host     string   `default:"localhost" env:"ENVI_TEST_CONCURRENT_HOST"`
+		YAMLFile YAMLFile `default:"./testdata/valid.yaml"`
+	}
+
+	e := envi.New()
+
+	var wg sync.WaitGroup
+
+	for i := range 10 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			var config Config
+
+			e.SetEnvOverride("ENVI_TEST_CONCURRENT_HOST", fmt.Sprintf("host-%d", i))
+
+			if err := e.Load(&config); err != nil {
+				t.Error(err)
+			}
+
+			if err := e.Reload(); err != nil {
+				t.Error(err)
+			}
+
+			e.LoadOrder()
+			e.ExplainKey("ENVI_TEST_CONCURRENT_HOST")
+			e.CompareWithEnvironment()
+		}()
+	}
+
+	wg.Wait()
+
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type CommonConfig struct {
+	LogLevel string `default:"info" env:"ENVI_TEST_EMBEDDED_LOG_LEVEL"`
+	Region   string `env:"ENVI_TEST_EMBEDDED_REGION" required:"true"`
+}
+
+type TracingConfig struct {
+	Endpoint string `default:"localhost:4317" env:"ENVI_TEST_EMBEDDED_ENDPOINT"`
+}
+
+func Test_EmbeddedStructs(t *testing.T) {
+	type YAMLFile struct {
+		Shell string `yaml:"SHELL"`
+	}
+
+	type Config struct {
+		CommonConfig
+		*TracingConfig
+		ServiceName string   `default:"envi" env:"ENVI_TEST_EMBEDDED_SERVICE_NAME"`
+		YAMLFile    YAMLFile `default:"./testdata/valid.yaml"`
+	}
+
+	testCases := map[string]struct {
+		region         string
+		logLevel       string
+		expectedConfig Config
+		expectedErr    error
+	}{
+		"embedded fields from env and defaults": {
+			region: "eu-central-1",
+			expectedConfig: Config{
+				CommonConfig:  CommonConfig{LogLevel: "info", Region: "eu-central-1"},
+				TracingConfig: &TracingConfig{Endpoint: "localhost:4317"},
+				ServiceName:   "envi",
+				YAMLFile:      YAMLFile{Shell: "csh"},
+			},
+		},
+		"embedded field overrides default": {
+			region:   "eu-west-1",
+			logLevel: "debug",
+			expectedConfig: Config{
+				CommonConfig:  CommonConfig{LogLevel: "debug", Region: "eu-west-1"},
+				TracingConfig: &TracingConfig{Endpoint: "localhost:4317"},
+				ServiceName:   "envi",
+				YAMLFile:      YAMLFile{Shell: "csh"},
+			},
+		},
+		"required embedded field missing": {
+			expectedErr: errors.New("field Region is required\n"),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("ENVI_TEST_EMBEDDED_REGION", tc.region)
+			t.Setenv("ENVI_TEST_EMBEDDED_LOG_LEVEL", tc.logLevel)
+
+			var config Config
+
+			err := envi.New().Load(&config)
+
+			switch {
+			case err == nil && tc.expectedErr != nil:
+				t.Fatalf("expected error %v but got nil", tc.expectedErr)
+			case err != nil && tc.expectedErr == nil:
+				t.Fatalf("expected no error but got %v", err)
+			case err != nil && tc.expectedErr != nil:
+				if errors.Unwrap(err).Error() != tc.expectedErr.Error() {
+					t.Fatalf("expected error %v but got %v", tc.expectedErr, errors.Unwrap(err))
+				}
+
+				return
+			}
+
+			if !reflect.DeepEqual(config, tc.expectedConfig) {
+				t.Errorf("expected config %+v but got %+v", tc.expectedConfig, config)
+			}
+		})
+	}
+}
+
+func Test_Transform(t *testing.T) {
+	type TransformFile struct {
+		Shell  string `yaml:"SHELL"`
+		Region string `default:"  EU-CENTRAL-1 " transform:"trim,lower"`
+	}
+
+	type Config struct {
+		Environment string        `env:"ENVI_TEST_TRANSFORM_ENVIRONMENT" transform:"trim,lower"`
+		PrefixFirst string        `default:"xab" transform:"trimprefix:x,upper"`
+		UpperFirst  string        `default:"xab" transform:"upper,trimprefix:x"`
+		Version     string        `default:"v1.2.3-beta" transform:"trimprefix:v,trimsuffix:-beta"`
+		Port        int           `env:"ENVI_TEST_TRANSFORM_PORT" transform:"trim"`
+		YAMLFile    TransformFile `default:"./testdata/valid.yaml"`
+	}
+
+	type InvalidConfig struct {
+		Environment string `default:"prod" transform:"trim,reverse"`
+	}
+
+	testCases := map[string]struct {
+		environment    string
+		port           string
+		config         any
+		expectedConfig any
+		expectedErr    error
+	}{
+		"chained transforms in order": {
+			environment: "  Production  ",
+			port:        " 8080 ",
+			config:      &Config{},
+			expectedConfig: &Config{
+				Environment: "production",
+				PrefixFirst: "AB",
+				UpperFirst:  "XAB",
+				Version:     "1.2.3",
+				Port:        8080,
+				YAMLFile:    TransformFile{Shell: "csh", Region: "eu-central-1"},
+			},
+		},
+		"unknown transform": {
+			config:      &InvalidConfig{},
+			expectedErr: errors.New("error while loading config: invalid tag transform: unknown transform \"reverse\""),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("ENVI_TEST_TRANSFORM_ENVIRONMENT", tc.environment)
+			t.Setenv("ENVI_TEST_TRANSFORM_PORT", tc.port)
+
+			err := envi.New().Load(tc.config)
+
+			switch {
+			case err == nil && tc.expectedErr != nil:
+				t.Fatalf("expected error %v but got nil", tc.expectedErr)
+			case err != nil && tc.expectedErr == nil:
+				t.Fatalf("expected no error but got %v", err)
+			case err != nil && tc.expectedErr != nil:
+				if errors.Unwrap(err).Error() != tc.expectedErr.Error() {
+					t.Fatalf("expected error %v but got %v", tc.expectedErr, errors.Unwrap(err))
+				}
+
+				return
+			}
+
+			if !reflect.DeepEqual(tc.config, tc.expectedConfig) {
+				t.Errorf("expected config %+v but got %+v", tc.expectedConfig, tc.config)
+			}
+		})
+	}
+}
+
+func Test_MustLoad(t *testing.T) {
+	type Config struct {
+		Port int `env:"ENVI_TEST_MUST_LOAD_PORT" required:"true"`
+	}
+
+	testCases := map[string]struct {
+		port           string
+		expectedConfig Config
+		expectedPanic  bool
+	}{
+		"valid config": {
+			port:           "8080",
+			expectedConfig: Config{Port: 8080},
+		},
+		"missing required field": {
+			expectedPanic: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("ENVI_TEST_MUST_LOAD_PORT", tc.port)
+
+			var config Config
+
+			defer func() {
+				recovered := recover()
+
+				switch {
+				case recovered == nil && tc.expectedPanic:
+					t.Fatal("expected panic but got none")
+				case recovered != nil && !tc.expectedPanic:
+					t.Fatalf("expected no panic but got %v", recovered)
+				case recovered != nil:
+					err, ok := recovered.(error)
+					if !ok {
+						t.Fatalf("expected panic value to be an error but got %T", recovered)
+					}
+
+					var validationErr *envi.ValidationError
+					if !errors.As(err, &validationErr) {
+						t.Fatalf("expected ValidationError but got %v", err)
+					}
+
+					return
+				}
+
+				if config != tc.expectedConfig {
+					t.Errorf("expected config %+v but got %+v", tc.expectedConfig, config)
+				}
+			}()
+
+			envi.New().MustLoad(&config)
+		})
+	}
+}
+
+func Test_Redacted(t *testing.T) {
+	type Credentials struct {
+		User     string `yaml:"USER"`
+		Password string `yaml:"PASSWORD" sensitive:"true"`
+	}
+
+	type Config struct {
+		APIKey      string       `env:"API_KEY" required:"true" sensitive:"true"`
+		ServiceName string       `default:"envi" sensitive:"false"`
+		Port        *int         `env:"PORT"`
+		Credentials Credentials  `default:"./credentials.yaml"`
+		Backup      *Credentials `env:"BACKUP_CREDENTIALS"`
+		secret      string
+	}
+
+	testCases := map[string]struct {
+		config   any
+		expected string
+	}{
+		"sensitive values are masked": {
+			config: &Config{
+				APIKey:      "api-key",
+				ServiceName: "envi",
+				Credentials: Credentials{User: "admin", Password: "password"},
+				Backup:      &Credentials{User: "backup", Password: "backup-password"},
+				secret:      "secret",
+			},
+			expected: "{APIKey:*** ServiceName:envi Port:<nil> Credentials:{User:admin Password:***} Backup:{User:backup Password:***}}",
+		},
+		"config value works like pointer": {
+			config:   Config{APIKey: "api-key"},
+			expected: "{APIKey:*** ServiceName: Port:<nil> Credentials:{User: Password:***} Backup:<nil>}",
+		},
+		"nil config": {
+			config:   nil,
+			expected: "<nil>",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			redacted := envi.New().Redacted(tc.config)
+
+			if redacted != tc.expected {
+				t.Errorf("expected %q but got %q", tc.expected, redacted)
+			}
+		})
+	}
+}
+
+func Test_LoadCtx(t *testing.T) {
+	type YAMLFile struct {
+		Shell string `yaml:"SHELL"`
+	}
+
+	type JSONFile struct {
+		Editor string `json:"EDITOR"`
+	}
+
+	type Config struct {
+		Name     string   `default:"envi"`
+		YAMLFile YAMLFile `default:"./testdata/valid.yaml"`
+		JSONFile JSONFile `default:"./testdata/valid.json" type:"json"`
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	t.Cleanup(cancelExpired)
+
+	testCases := map[string]struct {
+		ctx            context.Context
+		options        []envi.Option
+		expectedConfig Config
+		expectedErr    error
+	}{
+		"active context": {
+			ctx: context.Background(),
+			expectedConfig: Config{
+				Name:     "envi",
+				YAMLFile: YAMLFile{Shell: "csh"},
+				JSONFile: JSONFile{Editor: "emacs"},
+			},
+		},
+		"cancelled context": {
+			ctx:         cancelled,
+			expectedErr: context.Canceled,
+		},
+		"expired context": {
+			ctx:         expired,
+			expectedErr: context.DeadlineExceeded,
+		},
+		"cancelled context with concurrent load": {
+			ctx:         cancelled,
+			options:     []envi.Option{envi.WithConcurrentLoad(2)},
+			expectedErr: context.Canceled,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var config Config
+
+			err := envi.New(tc.options...).LoadCtx(tc.ctx, &config)
+
+			switch {
+			case err == nil && tc.expectedErr != nil:
+				t.Fatalf("expected error %v but got nil", tc.expectedErr)
+			case err != nil && tc.expectedErr == nil:
+				t.Fatalf("expected no error but got %v", err)
+			case err != nil && tc.expectedErr != nil:
+				if !errors.Is(err, tc.expectedErr) {
+					t.Fatalf("expected error wrapping %v but got %v", tc.expectedErr, err)
+				}
+
+				return
+			}
+
+			if config != tc.expectedConfig {
+				t.Errorf("expected config %+v but got %+v", tc.expectedConfig, config)
+			}
+		})
+	}
+}
+
+func Test_WithHashAlgorithm(t *testing.T) {
+	type Config struct {
+		File ReloadFile `env:"ENVI_TEST_HASH_FILE"`
+	}
+
+	testCases := map[string]struct {
+		options []envi.Option
+		warmUp  bool
+	}{
+		"default": {},
+		"md5": {
+			options: []envi.Option{envi.WithHashAlgorithm(envi.HashMD5)},
+		},
+		"sha256": {
+			options: []envi.Option{envi.WithHashAlgorithm(envi.HashSHA256)},
+		},
+		"sha256 with warm up": {
+			options: []envi.Option{envi.WithHashAlgorithm(envi.HashSHA256)},
+			warmUp:  true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "hash.yaml")
+
+			if err := os.WriteFile(path, []byte("NAME: peter"), 0o664); err != nil {
+				t.Fatal(err)
+			}
+
+			t.Setenv("ENVI_TEST_HASH_FILE", path)
+
+			e := envi.New(tc.options...)
+
+			if tc.warmUp {
+				if err := e.WarmUp([]string{path}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			config := Config{
+				File: ReloadFile{callbackCounter: new(atomic.Int32)},
+			}
+
+			if err := e.Load(&config); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := e.Reload(); err != nil {
+				t.Fatal(err)
+			}
+
+			if config.File.callbackCounter.Load() != 0 {
+				t.Fatalf("expected no OnChange call for an unchanged file but got %d calls", config.File.callbackCounter.Load())
+			}
+
+			if err := os.WriteFile(path, []byte("NAME: paul"), 0o664); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := e.Reload(); err != nil {
+				t.Fatal(err)
+			}
+
+			if config.File.Name != "paul" || config.File.callbackCounter.Load() != 1 {
+				t.Errorf("expected one OnChange call and reloaded config but got %+v with %d calls",
+					config.File, config.File.callbackCounter.Load())
+			}
+		})
+	}
+
+	t.Run("unknown algorithm", func(t *testing.T) {
+		var config struct{}
+
+		err := envi.New(envi.WithHashAlgorithm(envi.HashAlgo(0))).Load(&config)
+		if !errors.Is(err, envi.ErrUnknownHashAlgorithm) {
+			t.Errorf("expected unknown hash algorithm error but got %v", err)
+		}
+	})
+}
+
+func Test_TimeFields(t *testing.T) {
+	type TimeFile struct {
+		Shell    string    `yaml:"SHELL"`
+		Released time.Time `default:"2024-03-01" layout:"2006-01-02"`
+	}
+
+	type Config struct {
+		BuildTimestamp time.Time   `env:"ENVI_TEST_TIME_BUILD_TIMESTAMP" required:"true"`
+		CertExpiry     *time.Time  `env:"ENVI_TEST_TIME_CERT_EXPIRY" layout:"02.01.2006 15:04"`
+		Holidays       []time.Time `default:"2024-12-24;2024-12-31" layout:"2006-01-02" sep:";"`
+		YAMLFile       TimeFile    `default:"./testdata/valid.yaml"`
+	}
+
+	date := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	certExpiry := date(2025, time.June, 30, 12, 0)
+
+	testCases := map[string]struct {
+		buildTimestamp string
+		certExpiry     string
+		expectedConfig Config
+		expectedErr    error
+	}{
+		"default and custom layouts": {
+			buildTimestamp: "2024-05-17T08:30:00Z",
+			certExpiry:     "30.06.2025 12:00",
+			expectedConfig: Config{
+				BuildTimestamp: date(2024, time.May, 17, 8, 30),
+				CertExpiry:     &certExpiry,
+				Holidays:       []time.Time{date(2024, time.December, 24, 0, 0), date(2024, time.December, 31, 0, 0)},
+				YAMLFile:       TimeFile{Shell: "csh", Released: date(2024, time.March, 1, 0, 0)},
+			},
+		},
+		"optional time pointer stays nil": {
+			buildTimestamp: "2024-05-17T08:30:00Z",
+			expectedConfig: Config{
+				BuildTimestamp: date(2024, time.May, 17, 8, 30),
+				Holidays:       []time.Time{date(2024, time.December, 24, 0, 0), date(2024, time.December, 31, 0, 0)},
+				YAMLFile:       TimeFile{Shell: "csh", Released: date(2024, time.March, 1, 0, 0)},
+			},
+		},
+		"value does not match layout": {
+			buildTimestamp: "2024-05-17T08:30:00Z",
+			certExpiry:     "2025-06-30",
+			expectedErr:    errors.New("error while loading config: could not parse time.Time: parsing time \"2025-06-30\" as \"02.01.2006 15:04\": cannot parse \"25-06-30\" as \".\""),
+		},
+		"required time missing": {
+			expectedErr: errors.New("field BuildTimestamp is required\n"),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("ENVI_TEST_TIME_BUILD_TIMESTAMP", tc.buildTimestamp)
+			t.Setenv("ENVI_TEST_TIME_CERT_EXPIRY", tc.certExpiry)
+
+			var config Config
+
+			err := envi.New().Load(&config)
+
+			switch {
+			case err == nil && tc.expectedErr != nil:
+				t.Fatalf("expected error %v but got nil", tc.expectedErr)
+			case err != nil && tc.expectedErr == nil:
+				t.Fatalf("expected no error but got %v", err)
+			case err != nil && tc.expectedErr != nil:
+				if errors.Unwrap(err).Error() != tc.expectedErr.Error() {
+					t.Fatalf("expected error %v but got %v", tc.expectedErr, errors.Unwrap(err))
+				}
+
+				return
+			}
+
+			if !reflect.DeepEqual(config, tc.expectedConfig) {
+				t.Errorf("expected config %+v but got %+v", tc.expectedConfig, config)
+			}
+		})
+	}
+}
+
+func Test_WithDebounce(t *testing.T) {
+	type Config struct {
+		File ReloadFile `env:"ENVI_TEST_DEBOUNCE_FILE" watch:"true"`
+	}
+
+	path := filepath.Join(t.TempDir(), "debounce.yaml")
+
+	if err := os.WriteFile(path, []byte("NAME: peter"), 0o664); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("ENVI_TEST_DEBOUNCE_FILE", path)
+
+	e := envi.New(envi.WithDebounce(200 * time.Millisecond))
+	defer e.Close()
+
+	config := Config{
+		File: ReloadFile{callbackCounter: new(atomic.Int32)},
+	}
+
+	if err := e.Load(&config); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range 5 {
+		if err := os.WriteFile(path, []byte(fmt.Sprintf("NAME: peter-%d", i)), 0o664); err != nil {
+			t.Fatal(err)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	time.Sleep(time.Second)
+
+	if calls := config.File.callbackCounter.Load(); calls != 1 {
+		t.Errorf("expected OnChange to be called once after a burst of writes but got %d calls", calls)
+	}
+
+	t.Run("negative duration", func(t *testing.T) {
+		var config struct{}
+
+		err := envi.New(envi.WithDebounce(-time.Second)).Load(&config)
+		if !errors.Is(err, envi.ErrNegativeDebounce) {
+			t.Errorf("expected negative debounce error but got %v", err)
+		}
+	})
+}
+
+func Test_WithRetryOnError(t *testing.T) {
+	type Config struct {
+		File ReloadFile `env:"ENVI_TEST_RETRY_FILE" watch:"true"`
+	}
+
+	testCases := map[string]struct {
+		options       []envi.Option
+		expectedErr   bool
+		expectedCalls int32
+	}{
+		"without retry the error is reported": {
+			expectedErr:   true,
+			expectedCalls: 1,
+		},
+		"retry bridges a broken write": {
+			options:       []envi.Option{envi.WithRetryOnError(10, 50*time.Millisecond)},
+			expectedCalls: 1,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "retry.yaml")
+
+			// files are replaced by a rename, so the watcher never sees a partially written file
+			replaceFile := func(content string) {
+				tmp := filepath.Join(dir, "retry.tmp")
+
+				if err := os.WriteFile(tmp, []byte(content), 0o664); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := os.Rename(tmp, path); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			replaceFile("NAME: peter")
+
+			t.Setenv("ENVI_TEST_RETRY_FILE", path)
+
+			e := envi.New(tc.options...)
+			defer e.Close()
+
+			config := Config{
+				File: ReloadFile{callbackCounter: new(atomic.Int32)},
+			}
+
+			if err := e.Load(&config); err != nil {
+				t.Fatal(err)
+			}
+
+			replaceFile("NAME: [")
+
+			time.Sleep(200 * time.Millisecond)
+
+			replaceFile("NAME: paul")
+
+			time.Sleep(time.Second)
+
+			select {
+			case err := <-e.Errors():
+				if !tc.expectedErr {
+					t.Errorf("expected no error but got %v", err)
+				}
+			default:
+				if tc.expectedErr {
+					t.Error("expected an error but got none")
+				}
+			}
+
+			if calls := config.File.callbackCounter.Load(); calls != tc.expectedCalls {
+				t.Errorf("expected %d OnChange calls but got %d", tc.expectedCalls, calls)
+			}
+		})
+	}
+
+	t.Run("invalid options", func(t *testing.T) {
+		for _, tc := range []struct {
+			option      envi.Option
+			expectedErr error
+		}{
+			{option: envi.WithRetryOnError(-1, time.Second), expectedErr: envi.ErrNegativeRetries},
+			{option: envi.WithRetryOnError(1, -time.Second), expectedErr: envi.ErrNegativeRetryBackoff},
+		} {
+			var config struct{}
+
+			err := envi.New(tc.option).Load(&config)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected error %v but got %v", tc.expectedErr, err)
+			}
+		}
+	})
+}
+
+func Test_PrimitivePointerFields(t *testing.T) {
+	type PointerFile struct {
+		Shell   *string  `yaml:"SHELL"`
+		Editor  *string  `default:"vim" yaml:"EDITOR"`
+		Retries *int64   `default:"3" yaml:"RETRIES"`
+		Debug   *bool    `default:"true" yaml:"DEBUG"`
+		Ratio   *float64 `default:"0.5" yaml:"RATIO"`
+		Unset   *bool    `yaml:"UNSET"`
+	}
+
+	type Config struct {
+		Name     *string     `env:"ENVI_TEST_POINTER_NAME"`
+		Limit    *int64      `default:"100" env:"ENVI_TEST_POINTER_LIMIT"`
+		Enabled  *bool       `env:"ENVI_TEST_POINTER_ENABLED"`
+		YAMLFile PointerFile `default:"./testdata/valid.yaml"`
+	}
+
+	yamlFile := PointerFile{
+		Shell:   ptr("csh"),
+		Editor:  ptr("vim"),
+		Retries: ptr(int64(3)),
+		Debug:   ptr(true),
+		Ratio:   ptr(0.5),
+	}
+
+	testCases := map[string]struct {
+		envvars        map[string]string
+		expectedConfig Config
+		expectedErr    error
+	}{
+		"unset pointers stay nil": {
+			expectedConfig: Config{
+				Limit:    ptr(int64(100)),
+				YAMLFile: yamlFile,
+			},
+		},
+		"pointers are allocated from env vars": {
+			envvars: map[string]string{
+				"ENVI_TEST_POINTER_NAME":    "envi",
+				"ENVI_TEST_POINTER_LIMIT":   "-1",
+				"ENVI_TEST_POINTER_ENABLED": "false",
+			},
+			expectedConfig: Config{
+				Name:     ptr("envi"),
+				Limit:    ptr(int64(-1)),
+				Enabled:  ptr(false),
+				YAMLFile: yamlFile,
+			},
+		},
+		"invalid bool": {
+			envvars: map[string]string{
+				"ENVI_TEST_POINTER_ENABLED": "maybe",
+			},
+			expectedErr: errors.New("error while loading config: could not parse bool: strconv.ParseBool: parsing \"maybe\": invalid syntax"),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			for k, v := range tc.envvars {
+				t.Setenv(k, v)
+			}
+
+			var config Config
+
+			err := envi.New().Load(&config)
+
+			switch {
+			case err == nil && tc.expectedErr != nil:
+				t.Fatalf("expected error %v but got nil", tc.expectedErr)
+			case err != nil && tc.expectedErr == nil:
+				t.Fatalf("expected no error but got %v", err)
+			case err != nil && tc.expectedErr != nil:
+				if errors.Unwrap(err).Error() != tc.expectedErr.Error() {
+					t.Fatalf("expected error %v but got %v", tc.expectedErr, errors.Unwrap(err))
+				}
+
+				return
+			}
+
+			if !reflect.DeepEqual(config, tc.expectedConfig) {
+				t.Errorf("expected config %+v but got %+v", tc.expectedConfig, config)
+			}
+		})
+	}
+}
+
+// ptr returns a pointer to the given value.
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func Test_OneOf(t *testing.T) {
+	type OneOfFile struct {
+		Shell string `oneof:"bash,zsh,csh" yaml:"SHELL"`
+	}
+
+	type Config struct {
+		LogLevel    string    `default:"info" env:"ENVI_TEST_ONEOF_LOG_LEVEL" oneof:"debug,info,warn,error"`
+		Environment string    `env:"ENVI_TEST_ONEOF_ENVIRONMENT" oneofci:"dev, staging, prod"`
+		Region      *string   `env:"ENVI_TEST_ONEOF_REGION" oneof:"eu,us"`
+		YAMLFile    OneOfFile `default:"./testdata/valid.yaml"`
+	}
+
+	testCases := map[string]struct {
+		envvars     map[string]string
+		expectedErr error
+	}{
+		"allowed values": {
+			envvars: map[string]string{
+				"ENVI_TEST_ONEOF_LOG_LEVEL":   "debug",
+				"ENVI_TEST_ONEOF_ENVIRONMENT": "staging",
+				"ENVI_TEST_ONEOF_REGION":      "eu",
+			},
+		},
+		"case-insensitive match": {
+			envvars: map[string]string{
+				"ENVI_TEST_ONEOF_ENVIRONMENT": "PROD",
+			},
+		},
+		"unset optional fields are not checked": {},
+		"case-sensitive mismatch": {
+			envvars: map[string]string{
+				"ENVI_TEST_ONEOF_LOG_LEVEL": "DEBUG",
+			},
+			expectedErr: &envi.ValidationError{Errors: []error{
+				&envi.InvalidValueError{FieldName: "LogLevel", Value: "DEBUG", Allowed: []string{"debug", "info", "warn", "error"}},
+			}},
+		},
+		"multiple invalid values": {
+			envvars: map[string]string{
+				"ENVI_TEST_ONEOF_ENVIRONMENT": "test",
+				"ENVI_TEST_ONEOF_REGION":      "ap",
+			},
+			expectedErr: &envi.ValidationError{Errors: []error{
+				&envi.InvalidValueError{FieldName: "Environment", Value: "test", Allowed: []string{"dev", "staging", "prod"}},
+				&envi.InvalidValueError{FieldName: "Region", Value: "ap", Allowed: []string{"eu", "us"}},
+			}},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			for k, v := range tc.envvars {
+				t.Setenv(k, v)
+			}
+
+			var config Config
+
+			err := envi.New().Load(&config)
+
+			switch {
+			case err == nil && tc.expectedErr != nil:
+				t.Fatalf("expected error %v but got nil", tc.expectedErr)
+			case err != nil && tc.expectedErr == nil:
+				t.Fatalf("expected no error but got %v", err)
+			case err != nil && tc.expectedErr != nil:
+				if errors.Unwrap(err).Error() != tc.expectedErr.Error() {
+					t.Fatalf("expected error %v but got %v", tc.expectedErr, errors.Unwrap(err))
+				}
+			}
+		})
+	}
+}
+
+func Test_MinMax(t *testing.T) {
+	type RangeFile struct {
+		Shell string  `max:"3" yaml:"SHELL"`
+		Ratio float64 `default:"0.5" max:"1" min:"0"`
+	}
+
+	type Config struct {
+		PoolSize    int       `default:"10" env:"ENVI_TEST_RANGE_POOL_SIZE" max:"100" min:"1"`
+		Offset      int       `default:"0" env:"ENVI_TEST_RANGE_OFFSET" max:"-1" min:"-10"`
+		Workers     uint      `env:"ENVI_TEST_RANGE_WORKERS" max:"8"`
+		SecretKey   string    `env:"ENVI_TEST_RANGE_SECRET_KEY" min:"8" required:"true"`
+		Description *string   `env:"ENVI_TEST_RANGE_DESCRIPTION" max:"5"`
+		YAMLFile    RangeFile `default:"./testdata/valid.yaml"`
+	}
+
+	type InvalidTagConfig struct {
+		Port int `default:"80" min:"one"`
+	}
+
+	valid := map[string]string{
+		"ENVI_TEST_RANGE_OFFSET":     "-5",
+		"ENVI_TEST_RANGE_SECRET_KEY": "söme-sécret",
+	}
+
+	testCases := map[string]struct {
+		config      any
+		envvars     map[string]string
+		expectedErr error
+	}{
+		"values within range": {
+			config:  &Config{},
+			envvars: valid,
+		},
+		"bounds are inclusive": {
+			config: &Config{},
+			envvars: map[string]string{
+				"ENVI_TEST_RANGE_POOL_SIZE":   "100",
+				"ENVI_TEST_RANGE_OFFSET":      "-10",
+				"ENVI_TEST_RANGE_WORKERS":     "8",
+				"ENVI_TEST_RANGE_SECRET_KEY":  "12345678",
+				"ENVI_TEST_RANGE_DESCRIPTION": "short",
+			},
+		},
+		"values out of range": {
+			config: &Config{},
+			envvars: map[string]string{
+				"ENVI_TEST_RANGE_POOL_SIZE":   "0",
+				"ENVI_TEST_RANGE_OFFSET":      "0",
+				"ENVI_TEST_RANGE_WORKERS":     "9",
+				"ENVI_TEST_RANGE_SECRET_KEY":  "short",
+				"ENVI_TEST_RANGE_DESCRIPTION": "too long",
+			},
+			expectedErr: &envi.ValidationError{Errors: []error{
+				&envi.RangeViolationError{FieldName: "PoolSize", Min: "1", Max: "100", Got: "0"},
+				&envi.RangeViolationError{FieldName: "Offset", Min: "-10", Max: "-1", Got: "0"},
+				&envi.RangeViolationError{FieldName: "Workers", Max: "8", Got: "9"},
+				&envi.RangeViolationError{FieldName: "SecretKey", Min: "8", Got: "5"},
+				&envi.RangeViolationError{FieldName: "Description", Max: "5", Got: "8"},
+			}},
+		},
+		"required is checked before the range": {
+			config: &Config{},
+			envvars: map[string]string{
+				"ENVI_TEST_RANGE_OFFSET": "-5",
+			},
+			expectedErr: &envi.ValidationError{Errors: []error{
+				&envi.FieldRequiredError{FieldName: "SecretKey"},
+			}},
+		},
+		"invalid bound": {
+			config: &InvalidTagConfig{},
+			expectedErr: &envi.ValidationError{Errors: []error{
+				&envi.InvalidTagError{Tag: "min"},
+			}},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			for k, v := range tc.envvars {
+				t.Setenv(k, v)
+			}
+
+			err := envi.New().Load(tc.config)
+
+			switch {
+			case err == nil && tc.expectedErr != nil:
+				t.Fatalf("expected error %v but got nil", tc.expectedErr)
+			case err != nil && tc.expectedErr == nil:
+				t.Fatalf("expected no error but got %v", err)
+			case err != nil && tc.expectedErr != nil:
+				if errors.Unwrap(err).Error() != tc.expectedErr.Error() {
+					t.Fatalf("expected error %v but got %v", tc.expectedErr, errors.Unwrap(err))
+				}
+			}
+		})
+	}
+}
+
+func Test_Pattern(t *testing.T) {
+	type PatternFile struct {
+		Shell string `pattern:"^[a-z]+$" yaml:"SHELL"`
+	}
+
+	type Config struct {
+		Hostname string      `env:"ENVI_TEST_PATTERN_HOSTNAME" pattern:"^[a-z0-9-]+$"`
+		Contains string      `default:"my-service-prod" pattern:"service"`
+		YAMLFile PatternFile `default:"./testdata/valid.yaml"`
+	}
+
+	type InvalidFile struct {
+		Shell string `pattern:"[a-z" yaml:"SHELL"`
+	}
+
+	type InvalidConfig struct {
+		YAMLFile InvalidFile `default:"./testdata/valid.yaml"`
+	}
+
+	testCases := map[string]struct {
+		config      any
+		hostname    string
+		expectedErr error
+	}{
+		"full match": {
+			config:   &Config{},
+			hostname: "my-host-01",
+		},
+		"empty string is not checked": {
+			config: &Config{},
+		},
+		"partial match of anchored pattern": {
+			config:   &Config{},
+			hostname: "my-host.example.com",
+			expectedErr: &envi.ValidationError{Errors: []error{
+				&envi.PatternMismatchError{FieldName: "Hostname", Pattern: "^[a-z0-9-]+$", Value: "my-host.example.com"},
+			}},
+		},
+		"invalid pattern fails when loading": {
+			config:      &InvalidConfig{},
+			expectedErr: errors.New("error while loading config: invalid tag pattern"),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("ENVI_TEST_PATTERN_HOSTNAME", tc.hostname)
+
+			err := envi.New().Load(tc.config)
+
+			switch {
+			case err == nil && tc.expectedErr != nil:
+				t.Fatalf("expected error %v but got nil", tc.expectedErr)
+			case err != nil && tc.expectedErr == nil:
+				t.Fatalf("expected no error but got %v", err)
+			case err != nil && tc.expectedErr != nil:
+				if errors.Unwrap(err).Error() != tc.expectedErr.Error() {
+					t.Fatalf("expected error %v but got %v", tc.expectedErr, errors.Unwrap(err))
+				}
+			}
+		})
+	}
+}
+
+func Test_WithEnvPrefix(t *testing.T) {
+	type Config struct {
+		Host string `default:"localhost" env:"DB_HOST"`
+		Port int    `default:"5432" env:"DB_PORT"`
+		User string `env:"DB_USER"`
+	}
+
+	testCases := map[string]struct {
+		prefix         string
+		envvars        map[string]string
+		expectedConfig Config
+		expectedKeys   []string
+	}{
+		"prefixed variables are read": {
+			prefix: "ENVI_TEST_SERVICE_A_",
+			envvars: map[string]string{
+				"ENVI_TEST_SERVICE_A_DB_HOST": "a.example.com",
+				"ENVI_TEST_SERVICE_A_DB_USER": "peter",
+				"DB_HOST":                     "unprefixed.example.com",
+			},
+			expectedConfig: Config{Host: "a.example.com", Port: 5432, User: "peter"},
+			expectedKeys:   []string{"ENVI_TEST_SERVICE_A_DB_HOST", "ENVI_TEST_SERVICE_A_DB_PORT", "ENVI_TEST_SERVICE_A_DB_USER"},
+		},
+		"unprefixed variables are ignored": {
+			prefix: "ENVI_TEST_SERVICE_B_",
+			envvars: map[string]string{
+				"ENVI_TEST_SERVICE_A_DB_HOST": "a.example.com",
+				"DB_USER":                     "peter",
+			},
+			expectedConfig: Config{Host: "localhost", Port: 5432},
+			expectedKeys:   []string{"ENVI_TEST_SERVICE_B_DB_HOST", "ENVI_TEST_SERVICE_B_DB_PORT", "ENVI_TEST_SERVICE_B_DB_USER"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			for k, v := range tc.envvars {
+				t.Setenv(k, v)
+			}
+
+			e := envi.New(envi.WithEnvPrefix(tc.prefix))
+
+			var config Config
+
+			if err := e.Load(&config); err != nil {
+				t.Fatal(err)
+			}
+
+			if config != tc.expectedConfig {
+				t.Errorf("expected config %+v but got %+v", tc.expectedConfig, config)
+			}
+
+			if keys := e.Keys(); !reflect.DeepEqual(keys, tc.expectedKeys) {
+				t.Errorf("expected keys %v but got %v", tc.expectedKeys, keys)
+			}
+		})
+	}
+}
+
+func Test_RequiredWithDefault(t *testing.T) {
+	type EmptyFile struct {
+		Missing string `yaml:"MISSING"`
+	}
+
+	type RequiredChildFile struct {
+		Shell   string `required:"true" yaml:"SHELL"`
+		Missing string `required:"true" yaml:"MISSING"`
+	}
+
+	type DefaultConfig struct {
+		Retries int       `default:"0" env:"ENVI_TEST_REQUIRED_RETRIES" required:"true"`
+		Debug   bool      `default:"false" required:"true"`
+		File    EmptyFile `default:"./testdata/valid.yaml" required:"true"`
+	}
+
+	type ChildConfig struct {
+		File RequiredChildFile `default:"./testdata/valid.yaml" required:"true"`
+	}
+
+	testCases := map[string]struct {
+		config      any
+		expectedErr error
+	}{
+		"zero defaults satisfy required": {
+			config: &DefaultConfig{},
+		},
+		"required file struct does not apply to its fields": {
+			config: &ChildConfig{},
+			expectedErr: &envi.ValidationError{Errors: []error{
+				&envi.FieldRequiredError{FieldName: "Missing"},
+			}},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := envi.New().Load(tc.config)
+
+			switch {
+			case err == nil && tc.expectedErr != nil:
+				t.Fatalf("expected error %v but got nil", tc.expectedErr)
+			case err != nil && tc.expectedErr == nil:
+				t.Fatalf("expected no error but got %v", err)
+			case err != nil && tc.expectedErr != nil:
+				if errors.Unwrap(err).Error() != tc.expectedErr.Error() {
+					t.Fatalf("expected error %v but got %v", tc.expectedErr, errors.Unwrap(err))
+				}
+			}
+		})
+	}
+}
+
+func Test_Explain(t *testing.T) {
+	type Database struct {
+		URL  string `json:"DB_URL"`
+		Pool int    `default:"5"`
+	}
+
+	type YAMLFile struct {
+		Shell string `yaml:"SHELL,omitempty"`
+	}
+
+	type TextFile struct {
+		Value string
+	}
+
+	type Config struct {
+		CommonConfig
+		DatabaseURL string   `default:"postgres://localhost/mydb" env:"ENVI_TEST_EXPLAIN_DATABASE_URL"`
+		Host        string   `default:"localhost" env:"ENVI_TEST_EXPLAIN_MAP_HOST"`
+		User        string   `env:"ENVI_TEST_EXPLAIN_MAP_USER"`
+		Password    string   `default:"secret" env:"ENVI_TEST_EXPLAIN_PASSWORD" sensitive:"true"`
+		Database    Database `default:"./config.json" type:"json"`
+		YAMLFile    YAMLFile `env:"ENVI_TEST_EXPLAIN_YAML_FILE"`
+		TextFile    TextFile `default:"./value.txt" type:"text"`
+	}
+
+	t.Setenv("ENVI_TEST_EXPLAIN_DATABASE_URL", "postgres://db/prod")
+	t.Setenv("ENVI_TEST_EXPLAIN_YAML_FILE", "./config.yaml")
+	t.Setenv("ENVI_TEST_EMBEDDED_REGION", "eu-central-1")
+
+	e := envi.New()
+	e.SetEnvOverride("ENVI_TEST_EXPLAIN_MAP_HOST", "example.com")
+
+	testCases := map[string]struct {
+		config          any
+		expectedSources map[string]string
+		expectedErr     error
+	}{
+		"sources of all fields": {
+			config: &Config{},
+			expectedSources: map[string]string{
+				"Config.LogLevel":       "default:info",
+				"Config.Region":         "env:ENVI_TEST_EMBEDDED_REGION",
+				"Config.DatabaseURL":    "env:ENVI_TEST_EXPLAIN_DATABASE_URL",
+				"Config.Host":           "override:ENVI_TEST_EXPLAIN_MAP_HOST",
+				"Config.Password":       "default:***",
+				"Config.Database":       "default:./config.json",
+				"Config.Database.URL":   "file:./config.json#DB_URL",
+				"Config.Database.Pool":  "file:./config.json#Pool",
+				"Config.YAMLFile":       "env:ENVI_TEST_EXPLAIN_YAML_FILE",
+				"Config.YAMLFile.Shell": "file:./config.yaml#SHELL",
+				"Config.TextFile":       "default:./value.txt",
+				"Config.TextFile.Value": "file:./value.txt",
+			},
+		},
+		"no pointer": {
+			config:      Config{},
+			expectedErr: errors.New("expected field envi_test.Config to be kind pointer got struct"),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sources, err := e.Explain(tc.config)
+
+			switch {
+			case err == nil && tc.expectedErr != nil:
+				t.Fatalf("expected error %v but got nil", tc.expectedErr)
+			case err != nil && tc.expectedErr == nil:
+				t.Fatalf("expected no error but got %v", err)
+			case err != nil && tc.expectedErr != nil:
+				if errors.Unwrap(err).Error() != tc.expectedErr.Error() {
+					t.Fatalf("expected error %v but got %v", tc.expectedErr, errors.Unwrap(err))
+				}
+
+				return
+			}
+
+			if !reflect.DeepEqual(sources, tc.expectedSources) {
+				t.Errorf("expected sources %v but got %v", tc.expectedSources, sources)
+			}
+		})
+	}
+}
+
+func Test_Alias(t *testing.T) {
+	type Config struct {
+		DatabaseURL string `alias:"ENVI_TEST_ALIAS_DB_URL, ENVI_TEST_ALIAS_POSTGRES_URL" env:"ENVI_TEST_ALIAS_DATABASE_URL"`
+		Host        string `alias:"ENVI_TEST_ALIAS_HOSTNAME" default:"localhost" env:"ENVI_TEST_ALIAS_HOST"`
+	}
+
+	testCases := map[string]struct {
+		envvars        map[string]string
+		expectedConfig Config
+	}{
+		"primary is set": {
+			envvars: map[string]string{
+				"ENVI_TEST_ALIAS_DATABASE_URL": "postgres://primary",
+			},
+			expectedConfig: Config{DatabaseURL: "postgres://primary", Host: "localhost"},
+		},
+		"alias is set": {
+			envvars: map[string]string{
+				"ENVI_TEST_ALIAS_DB_URL":   "postgres://alias",
+				"ENVI_TEST_ALIAS_HOSTNAME": "example.com",
+			},
+			expectedConfig: Config{DatabaseURL: "postgres://alias", Host: "example.com"},
+		},
+		"second alias is set": {
+			envvars: map[string]string{
+				"ENVI_TEST_ALIAS_POSTGRES_URL": "postgres://second-alias",
+			},
+			expectedConfig: Config{DatabaseURL: "postgres://second-alias", Host: "localhost"},
+		},
+		"primary wins over aliases": {
+			envvars: map[string]string{
+				"ENVI_TEST_ALIAS_DATABASE_URL": "postgres://primary",
+				"ENVI_TEST_ALIAS_DB_URL":       "postgres://alias",
+				"ENVI_TEST_ALIAS_POSTGRES_URL": "postgres://second-alias",
+				"ENVI_TEST_ALIAS_HOST":         "primary.example.com",
+				"ENVI_TEST_ALIAS_HOSTNAME":     "alias.example.com",
+			},
+			expectedConfig: Config{DatabaseURL: "postgres://primary", Host: "primary.example.com"},
+		},
+		"neither is set": {
+			expectedConfig: Config{Host: "localhost"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			for k, v := range tc.envvars {
+				t.Setenv(k, v)
+			}
+
+			var config Config
+
+			if err := envi.New().Load(&config); err != nil {
+				t.Fatal(err)
+			}
+
+			if config != tc.expectedConfig {
+				t.Errorf("expected config %+v but got %+v", tc.expectedConfig, config)
+			}
+		})
+	}
+}
+
+func Test_Close(t *testing.T) {
+	type Config struct {
+		File ReloadFile `env:"ENVI_TEST_CLOSE_FILE" watch:"true"`
+	}
+
+	testCases := map[string]struct {
+		closeCalls int
+	}{
+		"single close": {
+			closeCalls: 1,
+		},
+		"double close does not panic": {
+			closeCalls: 2,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "close.yaml")
+
+			if err := os.WriteFile(path, []byte("NAME: peter"), 0o664); err != nil {
+				t.Fatal(err)
+			}
+
+			t.Setenv("ENVI_TEST_CLOSE_FILE", path)
+
+			e := envi.New()
+
+			config := Config{
+				File: ReloadFile{callbackCounter: new(atomic.Int32)},
+			}
+
+			if err := e.Load(&config); err != nil {
+				t.Fatal(err)
+			}
+
+			for range tc.closeCalls {
+				if err := e.Close(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if _, ok := <-e.Errors(); ok {
+				t.Error("expected the error channel to be closed")
+			}
+		})
+	}
+}
+
+func Test_WithErrorChannelSize(t *testing.T) {
+	type Config struct {
+		File ReloadFile `env:"ENVI_TEST_ERROR_CHANNEL_FILE" watch:"true"`
+	}
+
+	testCases := map[string]struct {
+		size    int
+		receive bool
+	}{
+		"unbuffered channel delivers the error": {
+			size:    0,
+			receive: true,
+		},
+		"buffered channel delivers the error": {
+			size:    1,
+			receive: true,
+		},
+		"unbuffered channel does not block close": {
+			size: 0,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "errors.yaml")
+
+			if err := os.WriteFile(path, []byte("NAME: peter"), 0o664); err != nil {
+				t.Fatal(err)
+			}
+
+			t.Setenv("ENVI_TEST_ERROR_CHANNEL_FILE", path)
+
+			e := envi.New(envi.WithErrorChannelSize(tc.size))
+
+			config := Config{
+				File: ReloadFile{callbackCounter: new(atomic.Int32)},
+			}
+
+			if err := e.Load(&config); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := os.WriteFile(path, []byte("NAME: ["), 0o664); err != nil {
+				t.Fatal(err)
+			}
+
+			if tc.receive {
+				select {
+				case <-e.Errors():
+				case <-time.After(2 * time.Second):
+					t.Error("expected an error but got none")
+				}
+			} else {
+				time.Sleep(200 * time.Millisecond)
+			}
+
+			closed := make(chan error)
+
+			go func() { closed <- e.Close() }()
+
+			select {
+			case err := <-closed:
+				if err != nil {
+					t.Fatal(err)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("expected close to return while a watcher waits to send an error")
+			}
+		})
+	}
+
+	t.Run("negative size", func(t *testing.T) {
+		var config struct{}
+
+		err := envi.New(envi.WithErrorChannelSize(-1)).Load(&config)
+		if !errors.Is(err, envi.ErrNegativeErrorChannelSize) {
+			t.Errorf("expected negative error channel size error but got %v", err)
+		}
+	})
+}
+
+func Test_PauseResume(t *testing.T) {
+	type Config struct {
+		File ReloadFile `env:"ENVI_TEST_PAUSE_FILE" watch:"true"`
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pause.yaml")
+
+	// files are replaced by a rename, so the watcher never sees a partially written file
+	replaceFile := func(content string) {
+		tmp := filepath.Join(dir, "pause.tmp")
+
+		if err := os.WriteFile(tmp, []byte(content), 0o664); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.Rename(tmp, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	replaceFile("NAME: peter")
+
+	t.Setenv("ENVI_TEST_PAUSE_FILE", path)
+
+	e := envi.New()
+	defer e.Close()
+
+	config := Config{
+		File: ReloadFile{callbackCounter: new(atomic.Int32)},
+	}
+
+	if err := e.Load(&config); err != nil {
+		t.Fatal(err)
+	}
+
+	e.Pause()
+
+	if !e.Paused() {
+		t.Error("expected the watchers to be paused")
+	}
+
+	for _, content := range []string{"NAME: paul", "NAME: ["} {
+		replaceFile(content)
+
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	if calls := config.File.callbackCounter.Load(); calls != 0 {
+		t.Errorf("expected no OnChange calls while paused but got %d", calls)
+	}
+
+	select {
+	case err := <-e.Errors():
+		t.Errorf("expected no error while paused but got %v", err)
+	default:
+	}
+
+	e.Resume()
+
+	replaceFile("NAME: mary")
+
+	time.Sleep(200 * time.Millisecond)
+
+	if calls := config.File.callbackCounter.Load(); calls != 1 {
+		t.Errorf("expected 1 OnChange call after resume but got %d", calls)
+	}
+
+	if config.File.Name != "mary" {
+		t.Errorf("expected name mary but got %s", config.File.Name)
+	}
+}
+
+// recordingLogger records all messages logged by envi.
+type recordingLogger struct {
+	mutex    sync.Mutex
+	messages []string
+}
+
+func (l *recordingLogger) Printf(format string, args ...any) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.messages = append(l.messages, fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) count(message string) int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	count := 0
+
+	for _, m := range l.messages {
+		if m == message {
+			count++
+		}
+	}
+
+	return count
+}
+
+func (l *recordingLogger) contains(message string) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return slices.Contains(l.messages, message)
+}
+
+func Test_WithLogger(t *testing.T) {
+	type Config struct {
+		File ReloadFile `env:"ENVI_TEST_LOGGER_FILE"`
+	}
+
+	path := filepath.Join(t.TempDir(), "logger.yaml")
+
+	if err := os.WriteFile(path, []byte("NAME: peter"), 0o664); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("ENVI_TEST_LOGGER_FILE", path)
+
+	logger := new(recordingLogger)
+
+	e := envi.New(envi.WithLogger(logger))
+	defer e.Close()
+
+	config := Config{
+		File: ReloadFile{callbackCounter: new(atomic.Int32)},
+	}
+
+	if err := e.Load(&config); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte("NAME: paul"), 0o664); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, message := range []string{
+		fmt.Sprintf("envi: file %s unchanged, skipping reload", path),
+		fmt.Sprintf("envi: hash of file %s changed, reloading", path),
+	} {
+		if !logger.contains(message) {
+			t.Errorf("expected message %q in %q", message, logger.messages)
+		}
+	}
+
+	t.Run("nil logger", func(t *testing.T) {
+		err := envi.New(envi.WithLogger(nil)).LoadRules(nil)
+		if !errors.Is(err, envi.ErrNilLogger) {
+			t.Errorf("expected nil logger error but got %v", err)
+		}
+	})
+}
+
+func Test_MapFields(t *testing.T) {
+	type Config struct {
+		Labels  map[string]string `env:"ENVI_TEST_MAP_LABELS" kvsep:":" required:"true"`
+		Limits  map[string]int    `default:"cpu=2; memory=512" sep:";"`
+		Headers map[string]string `env:"ENVI_TEST_MAP_HEADERS"`
+	}
+
+	testCases := map[string]struct {
+		labels         string
+		expectedConfig Config
+		expectedErr    error
+	}{
+		"custom separators": {
+			labels: "app:myapp,env:prod",
+			expectedConfig: Config{
+				Labels: map[string]string{"app": "myapp", "env": "prod"},
+				Limits: map[string]int{"cpu": 2, "memory": 512},
+			},
+		},
+		"values may contain the key value separator": {
+			labels: "url:http://localhost, ,",
+			expectedConfig: Config{
+				Labels: map[string]string{"url": "http://localhost"},
+				Limits: map[string]int{"cpu": 2, "memory": 512},
+			},
+		},
+		"missing key value separator": {
+			labels:      "app",
+			expectedErr: errors.New("error while loading config: could not parse map: missing key value separator \":\" in entry \"app\""),
+		},
+		"required map missing": {
+			expectedErr: errors.New("field Labels is required\n"),
+		},
+		"required map empty": {
+			labels:      ",",
+			expectedErr: errors.New("field Labels is required\n"),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("ENVI_TEST_MAP_LABELS", tc.labels)
+
+			var config Config
+
+			err := envi.New().Load(&config)
+
+			switch {
+			case err == nil && tc.expectedErr != nil:
+				t.Fatalf("expected error %v but got nil", tc.expectedErr)
+			case err != nil && tc.expectedErr == nil:
+				t.Fatalf("expected no error but got %v", err)
+			case err != nil && tc.expectedErr != nil:
+				if errors.Unwrap(err).Error() != tc.expectedErr.Error() {
+					t.Fatalf("expected error %v but got %v", tc.expectedErr, errors.Unwrap(err))
+				}
+
+				return
+			}
+
+			if !reflect.DeepEqual(config, tc.expectedConfig) {
+				t.Errorf("expected config %+v but got %+v", tc.expectedConfig, config)
+			}
+		})
+	}
+}
+
+// CircularConfig references itself through a nested struct without tags.
+type CircularConfig struct {
+	Name string `env:"ENVI_TEST_NESTED_CIRCULAR_NAME"`
+	Next *CircularConfig
+}
+
+func Test_NestedStructs(t *testing.T) {
+	type PoolConfig struct {
+		MaxConns int           `default:"10" env:"ENVI_TEST_NESTED_POOL_MAX_CONNS"`
+		Timeout  time.Duration `default:"5s"`
+	}
+
+	type DatabaseConfig struct {
+		Host string `env:"ENVI_TEST_NESTED_DB_HOST" required:"true"`
+		Pool PoolConfig
+	}
+
+	type YAMLFile struct {
+		Shell string `yaml:"SHELL"`
+	}
+
+	type Config struct {
+		Database DatabaseConfig
+		Files    *struct {
+			YAMLFile YAMLFile `default:"./testdata/valid.yaml"`
+		}
+	}
+
+	testCases := map[string]struct {
+		host           string
+		maxConns       string
+		expectedConfig Config
+		expectedErr    error
+	}{
+		"two levels deep": {
+			host:     "localhost",
+			maxConns: "20",
+			expectedConfig: Config{
+				Database: DatabaseConfig{
+					Host: "localhost",
+					Pool: PoolConfig{MaxConns: 20, Timeout: 5 * time.Second},
+				},
+				Files: &struct {
+					YAMLFile YAMLFile `default:"./testdata/valid.yaml"`
+				}{YAMLFile: YAMLFile{Shell: "csh"}},
+			},
+		},
+		"required field of nested struct missing": {
+			expectedErr: errors.New("field Host is required\n"),
+		},
+		"invalid value in nested struct": {
+			host:        "localhost",
+			maxConns:    "many",
+			expectedErr: errors.New("error while loading config: could not parse int: strconv.ParseInt: parsing \"many\": invalid syntax"),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("ENVI_TEST_NESTED_DB_HOST", tc.host)
+			t.Setenv("ENVI_TEST_NESTED_POOL_MAX_CONNS", tc.maxConns)
+
+			var config Config
+
+			err := envi.New().Load(&config)
+
+			switch {
+			case err == nil && tc.expectedErr != nil:
+				t.Fatalf("expected error %v but got nil", tc.expectedErr)
+			case err != nil && tc.expectedErr == nil:
+				t.Fatalf("expected no error but got %v", err)
+			case err != nil && tc.expectedErr != nil:
+				if errors.Unwrap(err).Error() != tc.expectedErr.Error() {
+					t.Fatalf("expected error %v but got %v", tc.expectedErr, errors.Unwrap(err))
+				}
+
+				return
+			}
+
+			if !reflect.DeepEqual(config, tc.expectedConfig) {
+				t.Errorf("expected config %+v but got %+v", tc.expectedConfig, config)
+			}
+		})
+	}
+
+	t.Run("circular struct definition", func(t *testing.T) {
+		var config CircularConfig
+
+		err := envi.New().Load(&config)
+
+		var depthErr *envi.NestingDepthError
+		if !errors.As(err, &depthErr) {
+			t.Fatalf("expected NestingDepthError but got %v", err)
+		}
+
+		if _, err := envi.New().Explain(&config); !errors.As(err, &depthErr) {
+			t.Errorf("expected NestingDepthError from Explain but got %v", err)
+		}
+	})
+
+	t.Run("explain", func(t *testing.T) {
+		t.Setenv("ENVI_TEST_NESTED_DB_HOST", "localhost")
+
+		sources, err := envi.New().Explain(&Config{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := map[string]string{
+			"Config.Database.Host":          "env:ENVI_TEST_NESTED_DB_HOST",
+			"Config.Database.Pool.MaxConns": "default:10",
+			"Config.Database.Pool.Timeout":  "default:5s",
+			"Config.Files.YAMLFile":         "default:./testdata/valid.yaml",
+			"Config.Files.YAMLFile.Shell":   "file:./testdata/valid.yaml#SHELL",
+		}
+
+		if !reflect.DeepEqual(sources, expected) {
+			t.Errorf("expected sources %v but got %v", expected, sources)
+		}
+	})
+}
+
+func Test_Export(t *testing.T) {
+	type YAMLFile struct {
+		Shell string `yaml:"SHELL"`
+	}
+
+	type Config struct {
+		Host     string    `default:"localhost" env:"ENVI_TEST_EXPORT_HOST"`
+		Greeting string    `env:"ENVI_TEST_EXPORT_GREETING"`
+		Tags     []string  `default:"a;b" sep:";"`
+		File     *YAMLFile `default:"./testdata/valid.yaml"`
+	}
+
+	testCases := map[string]struct {
+		format          string
+		expectedContent string
+		expectedErr     error
+	}{
+		"json": {
+			format: "json",
+			expectedContent: `{
+  "Host": "localhost",
+  "Greeting": "hello \"world\" # 1",
+  "Tags": [
+    "a",
+    "b"
+  ],
+  "File": {
+    "Shell": "csh"
+  }
+}`,
+		},
+		"yaml": {
+			format: "yaml",
+			expectedContent: `host: localhost
+greeting: 'hello "world" # 1'
+tags:
+    - a
+    - b
+file:
+    SHELL: csh
+`,
+		},
+		"env": {
+			format: "env",
+			expectedContent: `ENVI_TEST_EXPORT_GREETING="hello \"world\" # 1"
+ENVI_TEST_EXPORT_HOST=localhost
+File.Shell=csh
+Tags=a;b
+`,
+		},
+		"unsupported format": {
+			format:      "xml",
+			expectedErr: errors.New("unsupported format \"xml\", expected json, yaml or env"),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("ENVI_TEST_EXPORT_GREETING", `hello "world" # 1`)
+
+			path := filepath.Join(t.TempDir(), "export")
+
+			if err := os.WriteFile(path, []byte("stale"), 0o640); err != nil {
+				t.Fatal(err)
+			}
+
+			e := envi.New()
+
+			var config Config
+
+			if err := e.Load(&config); err != nil {
+				t.Fatal(err)
+			}
+
+			err := e.Export(&config, path, tc.format)
+
+			switch {
+			case err == nil && tc.expectedErr != nil:
+				t.Fatalf("expected error %v but got nil", tc.expectedErr)
+			case err != nil && tc.expectedErr == nil:
+				t.Fatalf("expected no error but got %v", err)
+			case err != nil && tc.expectedErr != nil:
+				if errors.Unwrap(err).Error() != tc.expectedErr.Error() {
+					t.Fatalf("expected error %v but got %v", tc.expectedErr, errors.Unwrap(err))
+				}
+
+				return
+			}
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(content) != tc.expectedContent {
+				t.Errorf("expected content %q but got %q", tc.expectedContent, content)
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if info.Mode().Perm() != 0o640 {
+				t.Errorf("expected the mode of the replaced file to be kept but got %s", info.Mode())
+			}
+		})
+	}
+
+	t.Run("exported env file can be loaded", func(t *testing.T) {
+		type DotenvFile struct {
+			Greeting string   `env:"ENVI_TEST_EXPORT_GREETING"`
+			Tags     []string `sep:";"`
+		}
+
+		type DotenvConfig struct {
+			File DotenvFile `env:"ENVI_TEST_EXPORT_FILE" type:"dotenv"`
+		}
+
+		t.Setenv("ENVI_TEST_EXPORT_GREETING", "hello \"world\" # 1\n\\o/")
+
+		path := filepath.Join(t.TempDir(), "export.env")
+
+		e := envi.New()
+
+		var config Config
+
+		if err := e.Load(&config); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := e.Export(&config, path, "env"); err != nil {
+			t.Fatal(err)
+		}
+
+		t.Setenv("ENVI_TEST_EXPORT_FILE", path)
+
+		var dotenvConfig DotenvConfig
+
+		if err := envi.New().Load(&dotenvConfig); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := DotenvFile{Greeting: config.Greeting, Tags: config.Tags}
+
+		if !reflect.DeepEqual(dotenvConfig.File, expected) {
+			t.Errorf("expected %+v but got %+v", expected, dotenvConfig.File)
+		}
+	})
+}
+
+func Test_Validate(t *testing.T) {
+	type PoolConfig struct {
+		MaxConns int `env:"POOL_MAX_CONNS" max:"100" required:"true"`
+	}
+
+	type Config struct {
+		Environment string `env:"ENVIRONMENT" oneof:"dev,prod" required:"true"`
+		LogLevel    string `default:"info" required:"true"`
+		Pool        PoolConfig
+	}
+
+	testCases := map[string]struct {
+		config      any
+		expectedErr error
+	}{
+		"valid config": {
+			config: &Config{Environment: "dev", LogLevel: "info", Pool: PoolConfig{MaxConns: 10}},
+		},
+		"struct value": {
+			config: Config{Environment: "prod", LogLevel: "info", Pool: PoolConfig{MaxConns: 10}},
+		},
+		"violations of all fields are collected": {
+			config: &Config{Environment: "staging", LogLevel: "info", Pool: PoolConfig{MaxConns: 200}},
+			expectedErr: &envi.ValidationError{Errors: []error{
+				&envi.InvalidValueError{FieldName: "Environment", Value: "staging", Allowed: []string{"dev", "prod"}},
+				&envi.RangeViolationError{FieldName: "MaxConns", Max: "100", Got: "200"},
+			}},
+		},
+		"required fields missing": {
+			config: &Config{},
+			expectedErr: &envi.ValidationError{Errors: []error{
+				&envi.FieldRequiredError{FieldName: "Environment"},
+				&envi.FieldRequiredError{FieldName: "LogLevel"},
+				&envi.FieldRequiredError{FieldName: "MaxConns"},
+			}},
+		},
+		"no struct": {
+			config:      ptr("config"),
+			expectedErr: errors.New("expected field *string to be kind struct got string"),
+		},
+		"nil": {
+			config:      nil,
+			expectedErr: errors.New("expected field <nil> to be kind struct got invalid"),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := envi.Validate(tc.config)
+
+			switch {
+			case err == nil && tc.expectedErr != nil:
+				t.Fatalf("expected error %v but got nil", tc.expectedErr)
+			case err != nil && tc.expectedErr == nil:
+				t.Fatalf("expected no error but got %v", err)
+			case err != nil && tc.expectedErr != nil:
+				if err.Error() != tc.expectedErr.Error() {
+					t.Fatalf("expected error %v but got %v", tc.expectedErr, err)
+				}
+			}
+		})
+	}
+}
+
+func Test_EnviValidate(t *testing.T) {
+	type Config struct {
+		Environment string `cfg:"ENVIRONMENT" mandatory:"yes"`
+		Region      string `env:"REGION" required:"true"`
+	}
+
+	err := envi.New(envi.WithTagParser(cfgTagParser{})).Validate(&Config{})
+
+	expectedErr := &envi.ValidationError{Errors: []error{&envi.FieldRequiredError{FieldName: "Environment"}}}
+
+	if err == nil || err.Error() != expectedErr.Error() {
+		t.Errorf("expected error %v but got %v", expectedErr, err)
+	}
+}
+
+func Test_FilterByPrefix(t *testing.T) {
+	type Config struct {
+		DBHost  string `default:"localhost" env:"ENVI_TEST_FILTER_DB_HOST"`
+		DBPort  int    `env:"ENVI_TEST_FILTER_DB_PORT"`
+		AppName string `default:"envi" env:"ENVI_TEST_FILTER_APP_NAME"`
+	}
+
+	t.Setenv("ENVI_TEST_FILTER_DB_PORT", "5432")
+
+	e := envi.New()
+
+	var config Config
+
+	if err := e.Load(&config); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{"HOST": "localhost", "PORT": "5432"}
+
+	if values := e.FilterByPrefix("ENVI_TEST_FILTER_DB_"); !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected values %v but got %v", expected, values)
+	}
+
+	if keys := e.Keys(); len(keys) != 3 {
+		t.Errorf("expected the recorded keys to stay unchanged but got %v", keys)
+	}
+}
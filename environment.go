@@ -0,0 +1,119 @@
+package envi
+
+import (
+	"os"
+	"slices"
+	"strings"
+)
+
+// DiscrepancyType describes how an environment variable changed since it was loaded.
+type DiscrepancyType int
+
+const (
+	// DiscrepancyAdded means the environment variable was not set during Load but is set now.
+	DiscrepancyAdded DiscrepancyType = iota + 1
+	// DiscrepancyRemoved means the environment variable was set during Load but is not set anymore.
+	DiscrepancyRemoved
+	// DiscrepancyChanged means the environment variable holds a different value than during Load.
+	DiscrepancyChanged
+)
+
+func (t DiscrepancyType) String() string {
+	switch t {
+	case DiscrepancyAdded:
+		return "added"
+	case DiscrepancyRemoved:
+		return "removed"
+	case DiscrepancyChanged:
+		return "changed"
+	default:
+		return "unknown"
+	}
+}
+
+// Discrepancy describes an environment variable whose current value differs from the value seen during Load.
+// Source is the alias the loaded value was read from, or empty if it was read from Key itself.
+type Discrepancy struct {
+	Key             string
+	Source          string
+	LoadedValue     string
+	CurrentEnvValue string
+	Type            DiscrepancyType
+}
+
+// loadedEnvVar is the value of an environment variable seen during Load and the alias it was read from, if any.
+type loadedEnvVar struct {
+	value  string
+	source string
+}
+
+/*
+CompareWithEnvironment compares the environment variables read by Load with the current process environment
+and returns the discrepancies sorted by key. It does not reload anything and is meant to detect config drift
+in long-running processes.
+
+Overrides set with SetEnvOverride are not taken into account, only the process environment is compared.
+Variables whose value was read from an alias are compared by their primary name. Their current value is the
+primary variable if it is set now, otherwise the alias.
+*/
+func (e *Envi) CompareWithEnvironment() []Discrepancy {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	discrepancies := make([]Discrepancy, 0)
+
+	for key, loaded := range e.loadedEnv {
+		currentValue := os.Getenv(key)
+		if currentValue == "" && loaded.source != "" {
+			currentValue = os.Getenv(loaded.source)
+		}
+
+		loadedValue := loaded.value
+		if currentValue == loadedValue {
+			continue
+		}
+
+		discrepancy := Discrepancy{
+			Key:             key,
+			Source:          loaded.source,
+			LoadedValue:     loadedValue,
+			CurrentEnvValue: currentValue,
+			Type:            DiscrepancyChanged,
+		}
+
+		switch {
+		case loadedValue == "":
+			discrepancy.Type = DiscrepancyAdded
+		case currentValue == "":
+			discrepancy.Type = DiscrepancyRemoved
+		}
+
+		discrepancies = append(discrepancies, discrepancy)
+	}
+
+	slices.SortFunc(discrepancies, func(a, b Discrepancy) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+
+	return discrepancies
+}
+
+/*
+recordEnv remembers the value of the environment variable seen during Load. If the value was read from an
+alias, source is the alias and the value is recorded under the primary key.
+*/
+func (e *Envi) recordEnv(key, source string) {
+	if key == "" {
+		return
+	}
+
+	loaded := loadedEnvVar{value: os.Getenv(key)}
+	if source != "" && source != key {
+		loaded = loadedEnvVar{value: os.Getenv(source), source: source}
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.loadedEnv[key] = loaded
+}
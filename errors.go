@@ -1,10 +1,25 @@
 package envi
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 )
 
+// The errors of options passed to New with an invalid value. They are returned by Load wrapped in an InvalidOptionError.
+var (
+	ErrUnknownHashAlgorithm     = errors.New("unknown hash algorithm")
+	ErrNegativeDebounce         = errors.New("negative debounce duration")
+	ErrNegativeRetries          = errors.New("negative number of retries")
+	ErrNegativeRetryBackoff     = errors.New("negative retry backoff")
+	ErrNegativeErrorChannelSize = errors.New("negative error channel size")
+	ErrNilLogger                = errors.New("nil logger")
+	ErrNonPositivePollInterval  = errors.New("non-positive vault poll interval")
+	ErrNilVaultClient           = errors.New("nil vault client")
+	ErrNilContext               = errors.New("nil context")
+	ErrNilTagParser             = errors.New("nil tag parser")
+)
+
 // InvalidKindError is returned when a field is not of the expected kind.
 type InvalidKindError struct {
 	FieldName string
@@ -60,6 +75,7 @@ func (e *MissingTagError) Error() string {
 	return fmt.Sprintf("tag %s not set", e.Tag)
 }
 
+// InvalidTagError is returned when a tag holds an invalid value.
 type InvalidTagError struct {
 	Tag string
 }
@@ -68,6 +84,69 @@ func (e *InvalidTagError) Error() string {
 	return fmt.Sprintf("invalid tag %s", e.Tag)
 }
 
+// InvalidTransformError is returned when the "transform" tag holds an unknown transform.
+type InvalidTransformError struct {
+	Transform string
+}
+
+func (e *InvalidTransformError) Error() string {
+	return fmt.Sprintf("invalid tag transform: unknown transform %q", e.Transform)
+}
+
+// InvalidValueError is returned when the value of a field is not one of the values allowed by its "oneof" tag.
+type InvalidValueError struct {
+	FieldName string
+	Value     string
+	Allowed   []string
+}
+
+func (e *InvalidValueError) Error() string {
+	return fmt.Sprintf("field %s has invalid value %q, allowed values are %s", e.FieldName, e.Value, strings.Join(e.Allowed, ", "))
+}
+
+/*
+RangeViolationError is returned when the value of a field violates its "min" or "max" tag. Min and Max hold
+the values of the tags, empty if a tag is not set. For strings, Got is the number of characters.
+*/
+type RangeViolationError struct {
+	FieldName string
+	Min       string
+	Max       string
+	Got       string
+}
+
+func (e *RangeViolationError) Error() string {
+	switch {
+	case e.Min != "" && e.Max != "":
+		return fmt.Sprintf("field %s must be between %s and %s, got %s", e.FieldName, e.Min, e.Max, e.Got)
+	case e.Min != "":
+		return fmt.Sprintf("field %s must be at least %s, got %s", e.FieldName, e.Min, e.Got)
+	default:
+		return fmt.Sprintf("field %s must be at most %s, got %s", e.FieldName, e.Max, e.Got)
+	}
+}
+
+// PatternMismatchError is returned when the value of a field does not match the regular expression of its "pattern" tag.
+type PatternMismatchError struct {
+	FieldName string
+	Pattern   string
+	Value     string
+}
+
+func (e *PatternMismatchError) Error() string {
+	return fmt.Sprintf("field %s with value %q does not match pattern %s", e.FieldName, e.Value, e.Pattern)
+}
+
+// NestingDepthError is returned when nested structs of a config exceed the maximum depth, e.g. because of a circular struct definition.
+type NestingDepthError struct {
+	FieldName string
+	MaxDepth  int
+}
+
+func (e *NestingDepthError) Error() string {
+	return fmt.Sprintf("field %s exceeds the maximum nesting depth of %d", e.FieldName, e.MaxDepth)
+}
+
 // ParsingError is returned when an error occurs while parsing a value into a specific datatype.
 type ParsingError struct {
 	Type string
@@ -93,3 +172,173 @@ func (e *CloseError) Error() string {
 
 	return sb.String()
 }
+
+// Unwrap returns the collected errors, so errors.Is and errors.As can be used on them.
+func (e *CloseError) Unwrap() []error {
+	return e.Errors
+}
+
+// InvalidOptionError is returned when one or multiple options passed to New were called with an invalid value.
+type InvalidOptionError struct {
+	Errors []error
+}
+
+func (e *InvalidOptionError) Error() string {
+	sb := strings.Builder{}
+
+	for _, err := range e.Errors {
+		sb.WriteString(err.Error())
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// Unwrap returns the collected errors, so errors.Is can be used with the sentinel errors of the options.
+func (e *InvalidOptionError) Unwrap() []error {
+	return e.Errors
+}
+
+// UnsupportedFormatError is returned when a config is exported or read in an unsupported format.
+// Expected lists the supported formats.
+type UnsupportedFormatError struct {
+	Format   string
+	Expected string
+}
+
+func (e *UnsupportedFormatError) Error() string {
+	return fmt.Sprintf("unsupported format %q, expected %s", e.Format, e.Expected)
+}
+
+// ValidationSchemaError is returned when the loaded config does not match the given JSON schema.
+type ValidationSchemaError struct {
+	Err error
+}
+
+func (e *ValidationSchemaError) Error() string {
+	return fmt.Sprintf("config does not match schema: %s", e.Err.Error())
+}
+
+func (e *ValidationSchemaError) Unwrap() error {
+	return e.Err
+}
+
+// WarmUpError is returned when one or multiple files could not be read while warming up.
+type WarmUpError struct {
+	Errors []error
+}
+
+func (e *WarmUpError) Error() string {
+	sb := strings.Builder{}
+
+	for _, err := range e.Errors {
+		sb.WriteString(err.Error())
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// Unwrap returns the collected errors, so errors.Is and errors.As can be used on them.
+func (e *WarmUpError) Unwrap() []error {
+	return e.Errors
+}
+
+// LoadError is returned when one or multiple files could not be loaded concurrently.
+type LoadError struct {
+	Errors []error
+}
+
+func (e *LoadError) Error() string {
+	sb := strings.Builder{}
+
+	for _, err := range e.Errors {
+		sb.WriteString(err.Error())
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// Unwrap returns the collected errors, so errors.Is and errors.As can be used on them.
+func (e *LoadError) Unwrap() []error {
+	return e.Errors
+}
+
+// BatchLoadError is returned when one or multiple configs could not be loaded in a batch.
+type BatchLoadError struct {
+	Errors []error
+}
+
+func (e *BatchLoadError) Error() string {
+	sb := strings.Builder{}
+
+	for _, err := range e.Errors {
+		sb.WriteString(err.Error())
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// Unwrap returns the collected errors, so errors.Is and errors.As can be used on them.
+func (e *BatchLoadError) Unwrap() []error {
+	return e.Errors
+}
+
+// VaultNotConfiguredError is returned when a vault field is loaded without a configured vault server.
+type VaultNotConfiguredError struct{}
+
+func (e *VaultNotConfiguredError) Error() string {
+	return "vault is not configured, use WithVault or set VAULT_ADDR and VAULT_TOKEN"
+}
+
+// InvalidVaultPathError is returned when a vault path does not consist of a mount and a secret path.
+type InvalidVaultPathError struct {
+	Path string
+}
+
+func (e *InvalidVaultPathError) Error() string {
+	return fmt.Sprintf("invalid vault path %s, expected <mount>/<path>", e.Path)
+}
+
+// VaultRequestError is returned when vault responds with an unexpected status code.
+type VaultRequestError struct {
+	Path       string
+	StatusCode int
+}
+
+func (e *VaultRequestError) Error() string {
+	return fmt.Sprintf("could not read vault secret %s: unexpected status code %d", e.Path, e.StatusCode)
+}
+
+// HookPanicError is sent to the error channel when a hook panics.
+type HookPanicError struct {
+	EventType EventType
+	Value     any
+}
+
+func (e *HookPanicError) Error() string {
+	return fmt.Sprintf("hook for %s event panicked: %v", e.EventType, e.Value)
+}
+
+// ReloadError is returned when one or multiple files or vault secrets could not be reloaded.
+type ReloadError struct {
+	Errors []error
+}
+
+func (e *ReloadError) Error() string {
+	sb := strings.Builder{}
+
+	for _, err := range e.Errors {
+		sb.WriteString(err.Error())
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// Unwrap returns the collected errors, so errors.Is and errors.As can be used on them.
+func (e *ReloadError) Unwrap() []error {
+	return e.Errors
+}
@@ -0,0 +1,273 @@
+package envi
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// KeyExplanation describes how the value of an environment variable was determined by Load.
+type KeyExplanation struct {
+	Key        string
+	FinalValue string
+	Steps      []ExplainStep
+}
+
+/*
+ExplainStep describes a source that was consulted for a value. Source is a descriptor like the ones returned
+by LoadOrder, e.g. "env:DB_HOST". Applied is true for the step whose value was used.
+*/
+type ExplainStep struct {
+	Source  string
+	Value   string
+	Applied bool
+}
+
+/*
+ExplainKey returns how the value of the given key was determined by the last Load. The key is the value of
+the "env" tag, or the dot separated path of the field for fields without one, e.g. "Database.Host". The steps are listed in order of precedence:
+override, environment variable and default. For file-backed fields the final value is the path of the file.
+
+A KeyExplanation without steps is returned for unknown keys.
+*/
+func (e *Envi) ExplainKey(key string) KeyExplanation {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	explanation, ok := e.explanations[key]
+	if !ok {
+		return KeyExplanation{Key: key}
+	}
+
+	return explanation
+}
+
+// Keys returns the sorted keys of all values loaded by Load and LoadRules. The keys are the ones accepted by ExplainKey.
+func (e *Envi) Keys() []string {
+	e.mutex.RLock()
+
+	keys := make([]string, 0, len(e.explanations))
+	for key := range e.explanations {
+		keys = append(keys, key)
+	}
+
+	e.mutex.RUnlock()
+
+	slices.Sort(keys)
+
+	return keys
+}
+
+/*
+FilterByPrefix returns the final values of all loaded keys that start with prefix, see Keys. The prefix is
+stripped from the keys of the result, e.g. "DB_HOST" is returned as "HOST" for the prefix "DB_". This is
+useful to pass the settings of a single component on. The recorded values are not modified.
+*/
+func (e *Envi) FilterByPrefix(prefix string) map[string]string {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	values := make(map[string]string)
+
+	for key, explanation := range e.explanations {
+		if stripped, ok := strings.CutPrefix(key, prefix); ok {
+			values[stripped] = explanation.FinalValue
+		}
+	}
+
+	return values
+}
+
+// recordExplanation remembers the sources consulted for a single field. The fieldName is the dot separated
+// path of the field, so fields without an env tag in different nested structs do not share a key.
+func (e *Envi) recordExplanation(fieldName, envTag, defaultTag string) {
+	source := e.resolveSource(envTag, defaultTag)
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	explanation := KeyExplanation{Key: envTag}
+	if envTag == "" {
+		explanation.Key = fieldName
+	}
+
+	applied := -1
+
+	if envTag != "" {
+		explanation.Steps = append(explanation.Steps,
+			ExplainStep{Source: sourceOverride + envTag, Value: e.overrides[envTag]},
+			ExplainStep{Source: sourceEnv + envTag, Value: os.Getenv(envTag)},
+		)
+	}
+
+	if defaultTag != "" {
+		explanation.Steps = append(explanation.Steps, ExplainStep{Source: sourceDefault + fieldName, Value: defaultTag})
+	}
+
+	switch source {
+	case SourceOverride:
+		applied = 0
+	case SourceEnv:
+		applied = 1
+	case SourceDefault:
+		applied = len(explanation.Steps) - 1
+	}
+
+	if applied != -1 {
+		explanation.Steps[applied].Applied = true
+		explanation.FinalValue = explanation.Steps[applied].Value
+	}
+
+	e.explanations[explanation.Key] = explanation
+}
+
+// fileKeyTags maps the file types to the struct tag holding the key of a field in the file.
+var fileKeyTags = map[string]string{
+	"yaml":    "yaml",
+	"yml":     "yaml",
+	"json":    "json",
+	"toml":    "toml",
+	"dotenv":  tagEnv,
+	"env":     tagEnv,
+	typeVault: "json",
+}
+
+/*
+Explain describes where the value of every field of the config comes from, without loading anything.
+The keys of the result are the dot separated field paths starting with the name of the config type, e.g.
+"Config.DatabaseURL". The values are descriptions of the sources, e.g. "env:DATABASE_URL",
+"default:postgres://localhost/mydb" or "file:./config.yaml#DB_URL" for the fields of file structs.
+The defaults of fields tagged with sensitive:"true" are masked, e.g. "default:***".
+
+The sources are resolved like Load would resolve them now. Fields without a source are omitted.
+*/
+func (e *Envi) Explain(config any) (map[string]string, error) {
+	const errMsg = "error while explaining config: %w"
+
+	t := reflect.TypeOf(config)
+	if t == nil || t.Kind() != reflect.Pointer {
+		return nil, fmt.Errorf(errMsg, &InvalidKindError{
+			FieldName: fmt.Sprintf("%T", config),
+			Expected:  "pointer",
+			Got:       reflect.ValueOf(config).Kind().String(),
+		})
+	}
+
+	t = resolveTypePointer(t)
+
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf(errMsg, &InvalidKindError{
+			FieldName: t.Name(),
+			Expected:  "struct",
+			Got:       t.Kind().String(),
+		})
+	}
+
+	sources := make(map[string]string)
+
+	if err := e.explainFields(sources, t.Name(), t, 0); err != nil {
+		return nil, fmt.Errorf(errMsg, err)
+	}
+
+	return sources, nil
+}
+
+func (e *Envi) explainFields(sources map[string]string, prefix string, t reflect.Type, depth int) error {
+	for i := range t.NumField() {
+		f := t.Field(i)
+
+		embedded, nested := isEmbeddedStruct(f, e.tagParser), isNestedStruct(f, e.tagParser)
+
+		if embedded || nested {
+			if depth >= maxNestingDepth {
+				return &NestingDepthError{FieldName: f.Name, MaxDepth: maxNestingDepth}
+			}
+
+			nestedPrefix := prefix
+			if nested {
+				nestedPrefix = prefix + "." + f.Name
+			}
+
+			if err := e.explainFields(sources, nestedPrefix, resolveTypePointer(f.Type), depth+1); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if !f.IsExported() {
+			continue
+		}
+
+		envTag := e.envName(e.tagParser.EnvTag(f))
+		defaultTag := e.tagParser.DefaultTag(f)
+
+		if envTag == "" && defaultTag == "" {
+			return &MissingTagError{Tag: "env or default"}
+		}
+
+		envTag = e.aliasedEnvName(envTag, getStructTag(f, tagAlias))
+
+		source := e.valueSource(envTag, defaultTag)
+		if source == "" {
+			continue
+		}
+
+		// the default is the value itself, so it is masked for sensitive fields like by Redacted
+		if source == sourceDefault+defaultTag && boolTag(f, tagSensitive) {
+			source = sourceDefault + maskedValue
+		}
+
+		path := prefix + "." + f.Name
+		sources[path] = source
+
+		fieldType := resolveTypePointer(f.Type)
+		if !isFileStruct(fieldType) {
+			continue
+		}
+
+		typeTag := cmp.Or(e.tagParser.TypeTag(f), "yaml")
+
+		fileSource := sourceFile + cmp.Or(e.getEnv(envTag), defaultTag)
+		if typeTag == typeVault {
+			fileSource = sourceVault + cmp.Or(e.getEnv(envTag), defaultTag)
+		}
+
+		for j := range fieldType.NumField() {
+			if fileField := fieldType.Field(j); fileField.IsExported() {
+				sources[path+"."+fileField.Name] = fileSource + fileKey(fileField, typeTag)
+			}
+		}
+	}
+
+	return nil
+}
+
+// valueSource describes the source of a value like recordExplanation, e.g. "env:DB_HOST".
+func (e *Envi) valueSource(envTag, defaultTag string) string {
+	switch e.resolveSource(envTag, defaultTag) {
+	case SourceOverride:
+		return sourceOverride + envTag
+	case SourceEnv:
+		return sourceEnv + envTag
+	case SourceDefault:
+		return sourceDefault + defaultTag
+	default:
+		return ""
+	}
+}
+
+// fileKey returns the "#key" suffix naming the field in a file of the given type. Text files have no keys.
+func fileKey(f reflect.StructField, typeTag string) string {
+	tagName, ok := fileKeyTags[typeTag]
+	if !ok {
+		return ""
+	}
+
+	key, _, _ := strings.Cut(getStructTag(f, tagName), ",")
+
+	return "#" + cmp.Or(key, f.Name)
+}
@@ -0,0 +1,208 @@
+package envi
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+/*
+Export writes the values of the loaded config to the file at path, e.g. for debugging or to pass the assembled
+config to a child process. The format is one of:
+  - "json" or "yaml": the config is marshalled like by encoding/json or yaml.v3, including the content of
+    file-backed fields
+  - "env" or "dotenv": KEY=VALUE lines that can be loaded as dotenv file. The keys are the values of the "env"
+    tags or the dot separated field paths, the fields of file-backed structs are keyed by their path,
+    e.g. "YAMLFile.Shell". Slices, maps and times are formatted according to their "sep", "kvsep" and "layout" tags.
+
+The file is replaced atomically by writing a temporary file in the same directory and renaming it. As the values
+may contain secrets, a new file is only readable by its owner. An UnsupportedFormatError is returned for other formats.
+*/
+func (e *Envi) Export(config any, path, format string) error {
+	const errMsg = "error while exporting config: %w"
+
+	v := reflect.ValueOf(config)
+	if v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf(errMsg, &InvalidKindError{
+			FieldName: fmt.Sprintf("%T", config),
+			Expected:  "struct",
+			Got:       v.Kind().String(),
+		})
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+
+	switch format {
+	case "json":
+		data, err = json.MarshalIndent(v.Interface(), "", "  ")
+	case "yaml", "yml":
+		data, err = yaml.Marshal(v.Interface())
+	case "env", "dotenv":
+		data = marshalDotenv(e.exportValues(make(map[string]string), "", v, false))
+	default:
+		return fmt.Errorf(errMsg, &UnsupportedFormatError{Format: format, Expected: "json, yaml or env"})
+	}
+
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	if err := writeFileAtomic(path, data); err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	return nil
+}
+
+// exportValues collects the formatted values of all exported fields of v, keyed like described by Export.
+// The fields of file structs are always keyed by their path, as their env tags do not name environment variables.
+func (e *Envi) exportValues(values map[string]string, prefix string, v reflect.Value, inFile bool) map[string]string {
+	t := v.Type()
+
+	for i := range t.NumField() {
+		f := t.Field(i)
+
+		field := reflect.Indirect(v.Field(i))
+		if !field.IsValid() {
+			continue // nil pointers hold no value
+		}
+
+		if isEmbeddedStruct(f, e.tagParser) {
+			values = e.exportValues(values, prefix, field, inFile)
+
+			continue
+		}
+
+		if !f.IsExported() {
+			continue
+		}
+
+		fieldPath := prefix + f.Name
+
+		if isFileStruct(field.Type()) {
+			values = e.exportValues(values, fieldPath+".", field, inFile || !isNestedStruct(f, e.tagParser))
+
+			continue
+		}
+
+		key := fieldPath
+		if envTag := e.tagParser.EnvTag(f); envTag != "" && !inFile {
+			key = e.envName(envTag)
+		}
+
+		values[key] = exportValue(field, fieldFormat(f))
+	}
+
+	return values
+}
+
+// exportValue formats the value, so it is parsed back by setValueFormat with the same format.
+func exportValue(v reflect.Value, format valueFormat) string {
+	if isNetType(v.Type()) {
+		return formatValue(v)
+	}
+
+	if v.Type() == timeType {
+		return v.Interface().(time.Time).Format(format.layout)
+	}
+
+	switch v.Kind() {
+	case reflect.Slice:
+		parts := make([]string, v.Len())
+
+		for i := range v.Len() {
+			parts[i] = exportValue(v.Index(i), format)
+		}
+
+		return strings.Join(parts, format.sep)
+	case reflect.Map:
+		parts := make([]string, 0, v.Len())
+
+		iter := v.MapRange()
+		for iter.Next() {
+			parts = append(parts, iter.Key().String()+format.kvSep+exportValue(iter.Value(), format))
+		}
+
+		slices.Sort(parts)
+
+		return strings.Join(parts, format.sep)
+	default:
+		return formatValue(v)
+	}
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it to path, so readers never see
+// a partially written file. The mode of an existing file is kept.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*.tmp")
+	if err != nil {
+		return err
+	}
+
+	// the temporary file is removed if anything fails before the rename
+	defer os.Remove(tmp.Name())
+
+	if info, err := os.Stat(path); err == nil {
+		if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+			tmp.Close()
+
+			return err
+		}
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// marshalDotenv writes the values as sorted KEY=VALUE lines. Values that would not be read back unchanged
+// by the dotenv parser are double quoted.
+func marshalDotenv(values map[string]string) []byte {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	sb := strings.Builder{}
+
+	for _, key := range keys {
+		value := values[key]
+
+		if strings.ContainsAny(value, " \t\n\r#\"'\\") {
+			value = `"` + dotenvQuotes.Replace(value) + `"`
+		}
+
+		sb.WriteString(key)
+		sb.WriteString("=")
+		sb.WriteString(value)
+		sb.WriteString("\n")
+	}
+
+	return []byte(sb.String())
+}
+
+var dotenvQuotes = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`)
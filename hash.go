@@ -0,0 +1,37 @@
+package envi
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"fmt"
+)
+
+// HashAlgo is the hash algorithm used to detect whether a file or vault secret has changed.
+type HashAlgo int
+
+const (
+	// HashMD5 hashes files with MD5. It is the default.
+	HashMD5 HashAlgo = iota + 1
+	// HashSHA256 hashes files with SHA-256, for environments where the use of MD5 is not permitted.
+	HashSHA256
+)
+
+func (a HashAlgo) String() string {
+	switch a {
+	case HashMD5:
+		return "md5"
+	case HashSHA256:
+		return "sha256"
+	default:
+		return fmt.Sprintf("unknown hash algorithm %d", int(a))
+	}
+}
+
+// sum returns the hex encoded hash of the data.
+func (a HashAlgo) sum(data []byte) string {
+	if a == HashSHA256 {
+		return fmt.Sprintf("%x", sha256.Sum256(data))
+	}
+
+	return fmt.Sprintf("%x", md5.Sum(data))
+}
@@ -0,0 +1,150 @@
+package envi
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"time"
+)
+
+// EventType describes an event emitted by Envi.
+type EventType int
+
+const (
+	// EventLoad is emitted after each call of Load, Duration holds the time it took and Err the returned error.
+	EventLoad EventType = iota + 1
+	// EventReload is emitted after a watched file or vault secret was reloaded, whether it changed or not.
+	EventReload
+	// EventWatchError is emitted when reloading a watched file or vault secret failed.
+	EventWatchError
+	// EventWatchChange is emitted when a watched file or vault secret changed, Changes holds the changed fields.
+	EventWatchChange
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventLoad:
+		return "load"
+	case EventReload:
+		return "reload"
+	case EventWatchError:
+		return "watch error"
+	case EventWatchChange:
+		return "watch change"
+	default:
+		return fmt.Sprintf("unknown event %d", int(t))
+	}
+}
+
+// EventData holds the details of an event. Fields that do not apply to the event type are left empty.
+type EventData struct {
+	EventType EventType
+	FilePath  string
+	Duration  time.Duration
+	Err       error
+	Changes   []ConfigChange
+}
+
+// Unsubscribe deregisters the hook it was returned for. Calling it more than once has no effect.
+type Unsubscribe func()
+
+// hook is a handler registered with Hook or HookAll. An event of 0 matches all event types.
+type hook struct {
+	event EventType
+	fn    func(EventData)
+}
+
+/*
+Hook registers fn to be called for every event of the given type. Multiple hooks can be registered per event type.
+
+Hooks are called synchronously in the goroutine that triggered the event, so they have to return quickly.
+A panicking hook is recovered and a HookPanicError is sent to the error channel.
+*/
+func (e *Envi) Hook(event EventType, fn func(EventData)) Unsubscribe {
+	return e.addHook(hook{event: event, fn: fn})
+}
+
+// HookAll registers fn to be called for events of all types, see Hook.
+func (e *Envi) HookAll(fn func(EventData)) Unsubscribe {
+	return e.addHook(hook{fn: fn})
+}
+
+func (e *Envi) addHook(h hook) Unsubscribe {
+	e.hookMutex.Lock()
+	defer e.hookMutex.Unlock()
+
+	id := e.nextHookID
+	e.nextHookID++
+	e.hooks[id] = h
+
+	return func() {
+		e.hookMutex.Lock()
+		defer e.hookMutex.Unlock()
+
+		delete(e.hooks, id)
+	}
+}
+
+// hasHooks reports whether at least one hook is registered for the given event type.
+func (e *Envi) hasHooks(event EventType) bool {
+	e.hookMutex.RLock()
+	defer e.hookMutex.RUnlock()
+
+	for _, h := range e.hooks {
+		if h.event == 0 || h.event == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+// emit calls all hooks registered for the type of the event in the order they were registered.
+func (e *Envi) emit(data EventData) {
+	e.hookMutex.RLock()
+
+	ids := make([]uint64, 0, len(e.hooks))
+
+	for id, h := range e.hooks {
+		if h.event == 0 || h.event == data.EventType {
+			ids = append(ids, id)
+		}
+	}
+
+	slices.Sort(ids)
+
+	fns := make([]func(EventData), 0, len(ids))
+
+	for _, id := range ids {
+		fns = append(fns, e.hooks[id].fn)
+	}
+
+	e.hookMutex.RUnlock()
+
+	// hooks are called without holding the lock, so they are able to (un)register hooks themselves
+	for _, fn := range fns {
+		e.callHook(fn, data)
+	}
+}
+
+func (e *Envi) callHook(fn func(EventData), data EventData) {
+	defer func() {
+		if r := recover(); r != nil {
+			e.sendError(&HookPanicError{EventType: data.EventType, Value: r})
+		}
+	}()
+
+	fn(data)
+}
+
+// snapshot returns a deep copy of the field if hooks for EventWatchChange are registered, otherwise an invalid value.
+func (e *Envi) snapshot(field reflect.Value) reflect.Value {
+	if !e.hasHooks(EventWatchChange) {
+		return reflect.Value{}
+	}
+
+	before := reflect.New(field.Type()).Elem()
+	before.Set(deepCopy(field))
+
+	return before
+}
@@ -0,0 +1,117 @@
+package envi
+
+import "reflect"
+
+// maskedValue replaces the values of sensitive fields.
+const maskedValue = "***"
+
+// InspectResult holds the FieldInfo of all fields of an inspected config.
+type InspectResult struct {
+	Fields []FieldInfo
+}
+
+/*
+FieldInfo describes a loaded config field.
+
+FieldName is the dot separated path of the field, e.g. "YAMLFile.Shell". Source is one of SourceOverride,
+SourceEnv, SourceDefault, SourceFile or SourceVault, fields of file structs have the source of the file.
+An empty Source means that no value was loaded into the field. For SourceFile and SourceVault, SourcePath holds
+the path of the file or vault secret as set in the env or default tag.
+*/
+type FieldInfo struct {
+	FieldName     string
+	EnvTag        string
+	ResolvedValue string
+	Source        string
+	SourcePath    string
+	Required      bool
+	Watch         bool
+	Type          string
+	Sensitive     bool
+	Valid         bool
+}
+
+/*
+Inspect describes the current values of the config and the sources they were loaded from. It has to be
+called with the same pointer that was passed to Load. Nested structs are inspected recursively.
+
+Inspect does not load anything, the values of fields tagged with sensitive:"true" are masked.
+Valid is false for fields that would fail the validation of Load, e.g. required fields without a value or
+values violating their "oneof", "min", "max" or "pattern" tags.
+*/
+func (e *Envi) Inspect(config any) InspectResult {
+	result := InspectResult{Fields: make([]FieldInfo, 0)}
+
+	v := reflect.ValueOf(config)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return result
+	}
+
+	v = reflect.Indirect(v)
+	if v.Kind() != reflect.Struct {
+		return result
+	}
+
+	result.Fields = e.inspectFields(result.Fields, "", v, e.loadedOrigins(v), fieldOrigin{})
+
+	return result
+}
+
+func (e *Envi) inspectFields(fields []FieldInfo, prefix string, v reflect.Value, origins map[sourceKey]fieldOrigin, fileOrigin fieldOrigin) []FieldInfo {
+	t := v.Type()
+
+	for i := range t.NumField() {
+		f := t.Field(i)
+		field := v.Field(i)
+
+		// the fields of embedded structs are described like fields of the embedding struct
+		if isEmbeddedStruct(f, e.tagParser) {
+			if embedded := reflect.Indirect(field); embedded.IsValid() {
+				fields = e.inspectFields(fields, prefix, embedded, origins, fileOrigin)
+			}
+
+			continue
+		}
+
+		if !f.IsExported() {
+			continue
+		}
+
+		origin := fileOrigin
+		if fileOrigin.source == "" && (field.Kind() != reflect.Pointer || !field.IsNil()) {
+			origin = loadedSource(origins, reflect.Indirect(field))
+		}
+
+		info := FieldInfo{
+			FieldName:  prefix + f.Name,
+			EnvTag:     e.tagParser.EnvTag(f),
+			Source:     origin.source,
+			SourcePath: origin.path,
+			Required:   e.tagParser.RequiredTag(f),
+			Watch:      e.tagParser.WatchTag(f),
+			Type:       e.tagParser.TypeTag(f),
+			Sensitive:  boolTag(f, tagSensitive),
+		}
+
+		info.Valid = len(validateField(f, field, e.tagParser, true)) == 0
+
+		if isFileStruct(resolveTypePointer(field.Type())) {
+			fields = append(fields, info)
+
+			if elem := reflect.Indirect(field); elem.IsValid() {
+				fields = e.inspectFields(fields, info.FieldName+".", elem, origins, origin)
+			}
+
+			continue
+		}
+
+		info.ResolvedValue = formatValue(field)
+		if info.Sensitive {
+			info.ResolvedValue = maskedValue
+		}
+
+		fields = append(fields, info)
+	}
+
+	return fields
+}
@@ -0,0 +1,197 @@
+package envi
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// LintSeverity describes how severe a LintWarning is.
+type LintSeverity int
+
+const (
+	// LintSeverityError marks tag combinations that make Load fail.
+	LintSeverityError LintSeverity = iota + 1
+	// LintSeverityWarning marks tag combinations that most likely do not behave as intended.
+	LintSeverityWarning
+	// LintSeverityInfo marks tags that have no effect.
+	LintSeverityInfo
+)
+
+func (s LintSeverity) String() string {
+	switch s {
+	case LintSeverityError:
+		return "error"
+	case LintSeverityWarning:
+		return "warning"
+	case LintSeverityInfo:
+		return "info"
+	default:
+		return fmt.Sprintf("unknown severity %d", int(s))
+	}
+}
+
+// LintWarning describes a suspicious tag combination of a config field.
+// Field is the dot separated path of the field, e.g. "YAMLFile.Shell".
+type LintWarning struct {
+	Field    string
+	Severity LintSeverity
+	Message  string
+}
+
+func (w LintWarning) String() string {
+	return fmt.Sprintf("%s: %s: %s", w.Severity, w.Field, w.Message)
+}
+
+var fileWatcherType = reflect.TypeFor[FileWatcher]()
+
+/*
+Lint checks the envi tags of the config struct for common mistakes without loading any files or environment variables.
+Nested file structs and embedded structs are checked as well. It is meant to be run in tests, e.g. in TestMain, to catch config issues in CI.
+
+The following cases are reported:
+  - neither an "env" nor a "default" tag is set (error)
+  - a tag holds an invalid value, e.g. an unknown "type" or a "required" other than true or false (error)
+  - "watch" is set but the struct does not implement FileWatcher (warning)
+  - "watch" or "type" is set on a field that is not loaded from a file (warning)
+  - "required" is set on a bool, which makes false an invalid value (warning)
+  - "required" is set together with a "default", which always satisfies it (info)
+
+The tags are read like by an Envi instance created without WithTagParser, see Envi.Lint for custom tag parsers.
+*/
+func Lint(config any) []LintWarning {
+	return lint(config, DefaultTagParser{})
+}
+
+// Lint checks the config struct like the package level Lint, but reads the tags with the parser of the Envi instance.
+func (e *Envi) Lint(config any) []LintWarning {
+	return lint(config, e.tagParser)
+}
+
+func lint(config any, parser TagParser) []LintWarning {
+	t := reflect.TypeOf(config)
+	if t == nil {
+		return []LintWarning{{Severity: LintSeverityError, Message: "expected struct got nil"}}
+	}
+
+	t = resolveTypePointer(t)
+
+	if t.Kind() != reflect.Struct {
+		return []LintWarning{{
+			Field:    t.Name(),
+			Severity: LintSeverityError,
+			Message:  fmt.Sprintf("expected struct got %s", t.Kind()),
+		}}
+	}
+
+	return lintFields(make([]LintWarning, 0), "", t, false, 0, parser, make(map[reflect.Type]bool))
+}
+
+// LintToString formats the result of Lint as a human-readable report with one warning per line.
+// An empty string is returned if there is nothing to report.
+func LintToString(config any) string {
+	sb := strings.Builder{}
+
+	for _, warning := range Lint(config) {
+		sb.WriteString(warning.String())
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// lintFields appends the warnings for all exported fields of t. Fields of file structs are unmarshalled
+// from the file, so the "env" and "default" tags are not mandatory for them. seen holds the file structs
+// that are currently checked, so a file struct that refers to itself is checked only once.
+func lintFields(warnings []LintWarning, prefix string, t reflect.Type, inFile bool, depth int, parser TagParser, seen map[reflect.Type]bool) []LintWarning {
+	for i := range t.NumField() {
+		f := t.Field(i)
+
+		embedded := isEmbeddedStruct(f, parser)
+		nested := !inFile && isNestedStruct(f, parser)
+
+		if (embedded || nested) && depth >= maxNestingDepth {
+			warnings = append(warnings, LintWarning{
+				Field:    prefix + f.Name,
+				Severity: LintSeverityError,
+				Message:  fmt.Sprintf("struct exceeds the maximum nesting depth of %d", maxNestingDepth),
+			})
+
+			continue
+		}
+
+		if embedded {
+			warnings = lintFields(warnings, prefix, resolveTypePointer(f.Type), inFile, depth+1, parser, seen)
+
+			continue
+		}
+
+		if nested {
+			warnings = lintFields(warnings, prefix+f.Name+".", resolveTypePointer(f.Type), false, depth+1, parser, seen)
+
+			continue
+		}
+
+		if !f.IsExported() {
+			continue
+		}
+
+		name := prefix + f.Name
+		fieldType := resolveTypePointer(f.Type)
+		isFile := isFileStruct(fieldType)
+
+		warn := func(severity LintSeverity, msg string) {
+			warnings = append(warnings, LintWarning{Field: name, Severity: severity, Message: msg})
+		}
+
+		if _, err := ParseTag(f); err != nil {
+			warn(LintSeverityError, err.Error())
+
+			continue
+		}
+
+		typeTag := parser.TypeTag(f)
+		if !isFileType(typeTag) {
+			warn(LintSeverityError, (&InvalidTagError{Tag: tagType}).Error())
+
+			continue
+		}
+
+		defaultTag := parser.DefaultTag(f)
+
+		if !inFile && parser.EnvTag(f) == "" && defaultTag == "" {
+			warn(LintSeverityError, "neither env nor default tag is set")
+		}
+
+		if parser.WatchTag(f) {
+			switch {
+			case !isFile || inFile:
+				warn(LintSeverityWarning, "watch has no effect on fields that are not loaded from a file")
+			case !reflect.PointerTo(fieldType).Implements(fileWatcherType):
+				warn(LintSeverityWarning, "watch is set but the struct does not implement FileWatcher")
+			}
+		}
+
+		if typeTag != "" && (!isFile || inFile) {
+			warn(LintSeverityWarning, "type has no effect on fields that are not loaded from a file")
+		}
+
+		if parser.RequiredTag(f) {
+			if fieldType.Kind() == reflect.Bool {
+				warn(LintSeverityWarning, "required on a bool rejects false as a value")
+			}
+
+			if defaultTag != "" {
+				warn(LintSeverityInfo, "required has no effect because a default is set")
+			}
+		}
+
+		if isFile && !seen[fieldType] {
+			seen[fieldType] = true
+			warnings = lintFields(warnings, name+".", fieldType, true, depth+1, parser, seen)
+			delete(seen, fieldType)
+		}
+	}
+
+	return warnings
+}
@@ -0,0 +1,17 @@
+package envi
+
+// Logger receives the internal debug messages of envi. It is satisfied by log.Logger, zap.SugaredLogger and
+// most other Printf-compatible loggers.
+type Logger interface {
+	Printf(format string, args ...any)
+}
+
+// noopLogger discards all messages. It is used if no logger is set with WithLogger.
+type noopLogger struct{}
+
+func (noopLogger) Printf(string, ...any) {}
+
+// logf writes a debug message to the logger set with WithLogger.
+func (e *Envi) logf(format string, args ...any) {
+	e.logger.Printf("envi: "+format, args...)
+}
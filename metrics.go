@@ -0,0 +1,97 @@
+package envi
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+/*
+ExportPrometheusMetrics returns Prometheus collectors for the Envi instance, which can be registered by the caller,
+e.g. with prometheus.MustRegister(collectors...). All metric names are prefixed with the namespace.
+
+The collectors are:
+  - <namespace>_reloads_total: number of reloads of watched files and vault secrets
+  - <namespace>_errors_total: number of failed loads and reloads, including those triggered by Reload
+  - <namespace>_load_duration_seconds: histogram of the durations of Load calls
+  - <namespace>_watched_files: number of watched files and vault secrets
+
+The metrics are collected with a hook, so every call returns a new, independent set of collectors.
+The returned Unsubscribe removes the hook once the collectors are no longer used.
+*/
+func (e *Envi) ExportPrometheusMetrics(namespace string) ([]prometheus.Collector, Unsubscribe) {
+	reloads := prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reloads_total",
+		Help:      "Number of reloads of watched files and vault secrets.",
+	})
+
+	errorCount := prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "errors_total",
+		Help:      "Number of failed loads and reloads.",
+	})
+
+	loadDuration := prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "load_duration_seconds",
+		Help:      "Duration of config loads in seconds.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	watchedFiles := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "watched_files",
+		Help:      "Number of watched files and vault secrets.",
+	}, func() float64 {
+		e.mutex.RLock()
+		defer e.mutex.RUnlock()
+
+		return float64(len(e.fileWatchers))
+	})
+
+	// failed reloads of watchers emit EventReload and EventWatchError, only the former is counted
+	unsubscribe := e.HookAll(func(data EventData) {
+		switch data.EventType {
+		case EventLoad:
+			loadDuration.Observe(data.Duration.Seconds())
+
+			if data.Err != nil {
+				errorCount.Inc()
+			}
+		case EventReload:
+			reloads.Inc()
+
+			if data.Err != nil {
+				errorCount.Inc()
+			}
+		}
+	})
+
+	return []prometheus.Collector{reloads, errorCount, loadDuration, watchedFiles}, unsubscribe
+}
+
+/*
+RegisterPrometheusMetrics registers the collectors returned by ExportPrometheusMetrics with the registerer.
+A prometheus.AlreadyRegisteredError is returned if one of the metric names is already in use. In that case
+the collectors registered so far are unregistered again and the hook is removed.
+*/
+func (e *Envi) RegisterPrometheusMetrics(reg prometheus.Registerer, namespace string) error {
+	const errMsg = "error while registering prometheus metrics: %w"
+
+	collectors, unsubscribe := e.ExportPrometheusMetrics(namespace)
+
+	for i, collector := range collectors {
+		if err := reg.Register(collector); err != nil {
+			for _, registered := range collectors[:i] {
+				reg.Unregister(registered)
+			}
+
+			unsubscribe()
+
+			return fmt.Errorf(errMsg, err)
+		}
+	}
+
+	return nil
+}
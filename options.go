@@ -0,0 +1,231 @@
+package envi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// Option configures an Envi instance created by New.
+type Option func(*Envi)
+
+/*
+WithConcurrentLoad loads the file-backed fields of a config with up to maxGoroutines goroutines.
+A value of 1 loads the files sequentially, which is the default. A value <= 0 uses runtime.NumCPU().
+
+When loading concurrently, errors of all files are collected and returned together in a LoadError.
+Watchers are set up after all files have been loaded.
+*/
+func WithConcurrentLoad(maxGoroutines int) Option {
+	return func(e *Envi) {
+		if maxGoroutines <= 0 {
+			maxGoroutines = runtime.NumCPU()
+		}
+
+		e.loadGoroutines = maxGoroutines
+	}
+}
+
+// WithTagParser replaces the way envi reads struct tags, e.g. to support existing tag naming conventions.
+// If parser is nil, Load returns ErrNilTagParser.
+func WithTagParser(parser TagParser) Option {
+	return func(e *Envi) {
+		if parser == nil {
+			e.optionError(ErrNilTagParser)
+
+			return
+		}
+
+		e.tagParser = parser
+	}
+}
+
+/*
+WithVault configures the address and token of the HashiCorp Vault server used for fields with the
+type:"vault" tag. If the option is omitted, the VAULT_ADDR and VAULT_TOKEN environment variables are used.
+*/
+func WithVault(address, token string) Option {
+	return func(e *Envi) {
+		e.vault = newVaultClient(address, token, e.vaultHTTPClient)
+	}
+}
+
+/*
+WithVaultClient reads the secrets of fields with the type:"vault" tag with an existing client of the
+HashiCorp Vault API, e.g. one that renews its token or authenticates with AppRole. It replaces the client
+configured by a previous WithVault option. If client is nil, Load returns ErrNilVaultClient.
+*/
+func WithVaultClient(client *api.Client) Option {
+	return func(e *Envi) {
+		if client == nil {
+			e.optionError(ErrNilVaultClient)
+
+			return
+		}
+
+		e.vault = &vaultClient{apiClient: client}
+	}
+}
+
+/*
+WithVaultHTTPClient sets the HTTP client used for the requests to the vault server, e.g. to configure TLS
+certificates, a proxy or timeouts. It applies to the client configured by WithVault as well as to the one
+created from the VAULT_ADDR and VAULT_TOKEN environment variables, but not to the one passed to WithVaultClient.
+Defaults to a client with a timeout of 30 seconds.
+*/
+func WithVaultHTTPClient(client *http.Client) Option {
+	return func(e *Envi) {
+		e.vaultHTTPClient = client
+
+		if e.vault != nil && e.vault.apiClient == nil && client != nil {
+			e.vault.httpClient = client
+		}
+	}
+}
+
+/*
+WithVaultPollInterval sets the interval in which watched vault secrets are polled for changes. Defaults to one minute.
+If interval is not positive, Load returns ErrNonPositivePollInterval.
+*/
+func WithVaultPollInterval(interval time.Duration) Option {
+	return func(e *Envi) {
+		if interval <= 0 {
+			e.optionError(fmt.Errorf("%w %s", ErrNonPositivePollInterval, interval))
+
+			return
+		}
+
+		e.vaultPollInterval = interval
+	}
+}
+
+/*
+WithContext binds the lifecycle of the Envi instance to ctx. All watchers stop when ctx is done.
+Close still has to be called to close the error channel, it returns a CloseError wrapping the context
+error if ctx was done before. If ctx is nil, Load returns ErrNilContext.
+*/
+func WithContext(ctx context.Context) Option {
+	return func(e *Envi) {
+		if ctx == nil {
+			e.optionError(ErrNilContext)
+
+			return
+		}
+
+		e.ctx = ctx
+	}
+}
+
+/*
+WithEnvPrefix prepends prefix to the names of all environment variables read by Load and LoadRules, e.g.
+env:"DB_HOST" reads SERVICE_A_DB_HOST with the prefix "SERVICE_A_". Defaults are not affected.
+
+Overrides, LoadOrder, ExplainKey and Keys use the prefixed names.
+*/
+func WithEnvPrefix(prefix string) Option {
+	return func(e *Envi) {
+		e.envPrefix = prefix
+	}
+}
+
+/*
+WithHashAlgorithm sets the hash algorithm used to detect whether a file or vault secret has changed.
+Defaults to HashMD5. If the algorithm is unknown, Load returns ErrUnknownHashAlgorithm.
+*/
+func WithHashAlgorithm(algo HashAlgo) Option {
+	return func(e *Envi) {
+		if algo != HashMD5 && algo != HashSHA256 {
+			e.optionError(fmt.Errorf("%w %d", ErrUnknownHashAlgorithm, int(algo)))
+
+			return
+		}
+
+		e.hashAlgo = algo
+	}
+}
+
+/*
+WithDebounce delays the reload of a watched file until no further change event arrived for d.
+This collapses the bursts of events emitted by editors and atomic writes into a single reload.
+A value of 0 reloads on every event, which is the default. If d is negative, Load returns ErrNegativeDebounce.
+*/
+func WithDebounce(d time.Duration) Option {
+	return func(e *Envi) {
+		if d < 0 {
+			e.optionError(fmt.Errorf("%w %s", ErrNegativeDebounce, d))
+
+			return
+		}
+
+		e.debounce = d
+	}
+}
+
+/*
+WithRetryOnError retries a failed reload of a watched file up to maxRetries times, waiting backoff between
+the attempts, before the error is reported. This bridges files that are briefly incomplete or stale, e.g.
+while being written or on network filesystems. By default, errors are reported without a retry.
+If maxRetries or backoff is negative, Load returns ErrNegativeRetries or ErrNegativeRetryBackoff.
+*/
+func WithRetryOnError(maxRetries int, backoff time.Duration) Option {
+	return func(e *Envi) {
+		if maxRetries < 0 {
+			e.optionError(fmt.Errorf("%w %d", ErrNegativeRetries, maxRetries))
+
+			return
+		}
+
+		if backoff < 0 {
+			e.optionError(fmt.Errorf("%w %s", ErrNegativeRetryBackoff, backoff))
+
+			return
+		}
+
+		e.maxRetries = maxRetries
+		e.retryBackoff = backoff
+	}
+}
+
+/*
+WithErrorChannelSize sets the buffer size of the error channel returned by Errors. Defaults to 100.
+Errors that don't fit into the buffer are dropped. A size of 0 creates an unbuffered channel, watchers
+then block until their error is received or the Envi instance is closed.
+If n is negative, Load returns ErrNegativeErrorChannelSize.
+*/
+func WithErrorChannelSize(n int) Option {
+	return func(e *Envi) {
+		if n < 0 {
+			e.optionError(fmt.Errorf("%w %d", ErrNegativeErrorChannelSize, n))
+
+			return
+		}
+
+		e.errorChan = make(chan error, n)
+	}
+}
+
+/*
+WithLogger sets a logger that receives debug messages, e.g. about skipped reloads of unchanged files,
+stopping watchers or errors dropped because the error channel is full. By default, nothing is logged.
+If logger is nil, Load returns ErrNilLogger.
+*/
+func WithLogger(logger Logger) Option {
+	return func(e *Envi) {
+		if logger == nil {
+			e.optionError(ErrNilLogger)
+
+			return
+		}
+
+		e.logger = logger
+	}
+}
+
+// optionError records the error of an option called with an invalid value, the option is not applied.
+func (e *Envi) optionError(err error) {
+	e.optionErrs = append(e.optionErrs, err)
+}
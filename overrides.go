@@ -0,0 +1,77 @@
+package envi
+
+import (
+	"os"
+	"strings"
+)
+
+/*
+SetEnvOverride sets a value for the environment variable key that takes precedence over
+the process environment and over defaults in all subsequent calls to Load.
+
+The process environment itself is not modified. This is useful in tests, e.g. to point a
+single setting to a test container without touching the rest of the config.
+*/
+func (e *Envi) SetEnvOverride(key, value string) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.overrides[key] = value
+}
+
+// ClearOverride removes the override for the environment variable key.
+func (e *Envi) ClearOverride(key string) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	delete(e.overrides, key)
+}
+
+// ClearAllOverrides removes all overrides.
+func (e *Envi) ClearAllOverrides() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	clear(e.overrides)
+}
+
+// envName returns the name of the environment variable of an "env" tag, which is the tag with the prefix
+// set by WithEnvPrefix. Empty tags stay empty.
+func (e *Envi) envName(tag string) string {
+	if tag == "" {
+		return ""
+	}
+
+	return e.envPrefix + tag
+}
+
+/*
+aliasedEnvName returns envTag if its environment variable or override holds a value, otherwise the name of the
+first of the comma separated aliases that holds one. envTag is returned if none of them is set.
+The aliases get the prefix of WithEnvPrefix like the env tag.
+*/
+func (e *Envi) aliasedEnvName(envTag, aliasTag string) string {
+	if aliasTag == "" || e.getEnv(envTag) != "" {
+		return envTag
+	}
+
+	for _, alias := range strings.Split(aliasTag, ",") {
+		if name := e.envName(strings.TrimSpace(alias)); e.getEnv(name) != "" {
+			return name
+		}
+	}
+
+	return envTag
+}
+
+// getEnv returns the override for key if set, otherwise the value of the environment variable.
+func (e *Envi) getEnv(key string) string {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	if value, ok := e.overrides[key]; ok {
+		return value
+	}
+
+	return os.Getenv(key)
+}
@@ -0,0 +1,24 @@
+package envi
+
+/*
+Pause stops the watchers from processing changes of watched files and vault secrets, e.g. during a planned
+maintenance window or a config migration. The underlying file watchers stay subscribed: change events and
+watcher errors that arrive while paused are dropped, so neither OnChange, OnError nor the error channel are
+triggered. Reload still works while paused.
+
+Pause and Resume are safe to call concurrently with the watchers.
+*/
+func (e *Envi) Pause() {
+	e.paused.Store(true)
+}
+
+// Resume continues processing changes of watched files and vault secrets after Pause. Changes made while paused
+// are picked up with the next change of the source or by calling Reload.
+func (e *Envi) Resume() {
+	e.paused.Store(false)
+}
+
+// Paused reports whether the watchers are paused.
+func (e *Envi) Paused() bool {
+	return e.paused.Load()
+}
@@ -0,0 +1,121 @@
+package envi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+/*
+LoadFromReader reads key-value pairs from r and merges them into the overrides of the instance, like
+SetEnvOverride. The keys are environment variable names, their values take precedence over the process
+environment and over defaults in all subsequent calls to Load. This feeds configs from stdin, HTTP responses
+or in-memory buffers in tests without writing a file. The format is one of:
+  - "json", "yaml" or "toml": an object of the keys and their values. Lists are joined with "," like the
+    default "sep" tag, nested objects are rejected with an UnmarshalError.
+  - "text", "env" or "dotenv": KEY=VALUE lines like in a dotenv file
+
+An empty reader merges nothing. An UnsupportedFormatError is returned for other formats. If reading or
+parsing r fails, no value is merged.
+*/
+func (e *Envi) LoadFromReader(r io.Reader, format string) error {
+	const errMsg = "error while loading from reader: %w"
+
+	var parse func([]byte) (map[string]string, error)
+
+	switch format {
+	case "json":
+		parse = readerValues(format, json.Unmarshal)
+	case "yaml", "yml":
+		parse = readerValues(format, yaml.Unmarshal)
+	case "toml":
+		parse = readerValues(format, toml.Unmarshal)
+	case "text", "env", "dotenv":
+		parse = func(data []byte) (map[string]string, error) {
+			values, err := parseDotenv(data)
+			if err != nil {
+				return nil, &UnmarshalError{Type: format, Err: err}
+			}
+
+			return values, nil
+		}
+	default:
+		return fmt.Errorf(errMsg, &UnsupportedFormatError{Format: format, Expected: "json, yaml, toml or text"})
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return nil
+	}
+
+	values, err := parse(data)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	maps.Copy(e.overrides, values)
+
+	return nil
+}
+
+// readerValues returns a parser that unmarshals an object with unmarshal and formats its values as strings.
+func readerValues(format string, unmarshal unmarshalFunc) func([]byte) (map[string]string, error) {
+	return func(data []byte) (map[string]string, error) {
+		var object map[string]any
+
+		if err := unmarshal(data, &object); err != nil {
+			return nil, &UnmarshalError{Type: format, Err: err}
+		}
+
+		values := make(map[string]string, len(object))
+
+		for key, value := range object {
+			formatted, err := formatReaderValue(value)
+			if err != nil {
+				return nil, &UnmarshalError{Type: format, Err: fmt.Errorf("key %s: %w", key, err)}
+			}
+
+			values[key] = formatted
+		}
+
+		return values, nil
+	}
+}
+
+// formatReaderValue formats a scalar or a list of scalars like the value of an environment variable.
+func formatReaderValue(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case []any:
+		elements := make([]string, len(v))
+
+		for i, element := range v {
+			formatted, err := formatReaderValue(element)
+			if err != nil {
+				return "", err
+			}
+
+			elements[i] = formatted
+		}
+
+		return strings.Join(elements, defaultSeparator), nil
+	case map[string]any:
+		return "", errors.New("nested objects are not supported")
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
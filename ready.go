@@ -0,0 +1,23 @@
+package envi
+
+import "context"
+
+/*
+WaitForReady blocks until the first call of Load or LoadRules succeeded or the context is done.
+It returns immediately if a load already succeeded, and ctx.Err() if the context is done first.
+*/
+func (e *Envi) WaitForReady(ctx context.Context) error {
+	select {
+	case <-e.ready:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// markReady unblocks all current and future calls of WaitForReady.
+func (e *Envi) markReady() {
+	e.readyOnce.Do(func() {
+		close(e.ready)
+	})
+}
@@ -0,0 +1,124 @@
+package envi
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+Reload re-reads all files and vault secrets that were loaded by Load or LoadRules, whether they are watched or not.
+Changed sources are unmarshalled into their fields again and OnChange is called if the field implements FileWatcher.
+
+Reload is safe to call concurrently with the watchers, e.g. from a SIGHUP handler. Errors of all sources
+are collected and returned together in a ReloadError.
+*/
+func (e *Envi) Reload() error {
+	e.mutex.RLock()
+
+	files := make([]fileField, 0, len(e.loadedFiles))
+	for _, file := range e.loadedFiles {
+		files = append(files, file)
+	}
+
+	vaults := make([]vaultField, 0, len(e.loadedVaults))
+	for _, vault := range e.loadedVaults {
+		vaults = append(vaults, vault)
+	}
+
+	e.mutex.RUnlock()
+
+	slices.SortFunc(files, func(a, b fileField) int {
+		return strings.Compare(a.path, b.path)
+	})
+
+	slices.SortFunc(vaults, func(a, b vaultField) int {
+		return strings.Compare(a.path, b.path)
+	})
+
+	errs := make([]error, 0)
+
+	for _, file := range files {
+		err := e.reloadSource(file.field, file.path, func() (bool, error) {
+			return e.loadFile(context.Background(), file)
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, vault := range vaults {
+		err := e.reloadSource(vault.field, sourceVault+vault.path, func() (bool, error) {
+			return e.loadVault(context.Background(), vault)
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return &ReloadError{Errors: errs}
+	}
+
+	return nil
+}
+
+/*
+reloadSource reloads a file or vault secret with load, emits the reload events and calls OnChange if the
+source changed and the field implements FileWatcher. Reloads of the same source are serialized, so the
+watchers and Reload do not write to the field at the same time.
+*/
+func (e *Envi) reloadSource(field reflect.Value, path string, load func() (bool, error)) error {
+	lock := e.sourceLock(path)
+	lock.Lock()
+
+	before := e.snapshot(field)
+	start := time.Now()
+
+	changed, err := load()
+
+	var changes []ConfigChange
+	if changed && before.IsValid() {
+		changes = changedFields(appendFieldDiffs(nil, "", before, field))
+	}
+
+	// the lock is released before calling OnChange, so the callback is able to call Reload itself
+	lock.Unlock()
+
+	e.emit(EventData{EventType: EventReload, FilePath: path, Duration: time.Since(start), Err: err})
+
+	if err != nil {
+		return err
+	}
+
+	if !changed {
+		return nil
+	}
+
+	if callback, ok := field.Addr().Interface().(FileWatcher); ok {
+		callback.OnChange()
+	}
+
+	if before.IsValid() {
+		e.emit(EventData{EventType: EventWatchChange, FilePath: path, Changes: changes})
+	}
+
+	return nil
+}
+
+// sourceLock returns the mutex that serializes the reloads of the file or vault secret at path.
+func (e *Envi) sourceLock(path string) *sync.Mutex {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	lock, ok := e.sourceLocks[path]
+	if !ok {
+		lock = new(sync.Mutex)
+		e.sourceLocks[path] = lock
+	}
+
+	return lock
+}
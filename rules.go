@@ -0,0 +1,141 @@
+package envi
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+/*
+LoadRule describes how to load a single value without struct tags.
+
+Key names the value in errors and in the load order. EnvVar, Default, Type, Required and Watch behave
+like the "env", "default", "type", "required" and "watch" tags. Destination has to be a pointer to a
+supported type, e.g. *string, *int or a pointer to a struct that is loaded from a file.
+*/
+type LoadRule struct {
+	Key         string
+	EnvVar      string
+	Default     string
+	Type        string
+	Required    bool
+	Watch       bool
+	Destination any
+}
+
+/*
+LoadRules loads the values described by the rules into their destinations and validates them.
+It is an alternative to Load for configs that are more naturally expressed as a list of rules.
+
+Example:
+
+	var port int
+	var database Database
+
+	err := e.LoadRules([]envi.LoadRule{
+		{Key: "Port", EnvVar: "PORT", Default: "8080", Destination: &port},
+		{Key: "Database", EnvVar: "DATABASE_CONFIG", Type: "json", Required: true, Destination: &database},
+	})
+*/
+func (e *Envi) LoadRules(rules []LoadRule) error {
+	start := time.Now()
+
+	err := e.loadRules(rules)
+	if err == nil {
+		e.markReady()
+	}
+
+	e.dropWarmFiles()
+
+	e.emit(EventData{EventType: EventLoad, Duration: time.Since(start), Err: err})
+
+	return err
+}
+
+func (e *Envi) loadRules(rules []LoadRule) error {
+	const errMsg = "error while loading rules: %w"
+
+	if len(e.optionErrs) > 0 {
+		return fmt.Errorf(errMsg, &InvalidOptionError{Errors: e.optionErrs})
+	}
+
+	sources := &sourceFields{parser: e.tagParser}
+	order := make([]string, 0)
+	fields := make([]reflect.Value, len(rules))
+
+	for i, rule := range rules {
+		dest := reflect.ValueOf(rule.Destination)
+
+		if dest.Kind() != reflect.Pointer || dest.IsNil() {
+			return fmt.Errorf(errMsg, &InvalidKindError{
+				FieldName: rule.Key,
+				Expected:  "pointer",
+				Got:       dest.Kind().String(),
+			})
+		}
+
+		if rule.EnvVar == "" && rule.Default == "" {
+			return fmt.Errorf(errMsg, &MissingTagError{Tag: "env or default"})
+		}
+
+		envVar := e.envName(rule.EnvVar)
+
+		order = append(order, e.fieldSources(rule.Key, envVar, rule.Default)...)
+
+		e.recordEnv(envVar, "")
+		e.recordExplanation(rule.Key, envVar, rule.Default)
+
+		field := resolveValuePointer(dest)
+		fields[i] = field
+
+		value := cmp.Or(e.getEnv(envVar), rule.Default)
+
+		switch {
+		case isFileStruct(field.Type()):
+			if err := sources.add(field, value, rule.Type, rule.Watch); err != nil {
+				return fmt.Errorf(errMsg, err)
+			}
+		case isParsable(field.Type()):
+			if value == "" && field.Kind() != reflect.String {
+				continue
+			}
+
+			if err := setValue(field, value); err != nil {
+				return fmt.Errorf(errMsg, err)
+			}
+		default:
+			return fmt.Errorf(errMsg, &InvalidKindError{
+				FieldName: rule.Key,
+				Expected:  "string, int, uint, float, bool, slice, map, time.Time, net.IPNet, net.HardwareAddr, struct",
+				Got:       field.Kind().String(),
+			})
+		}
+	}
+
+	if err := e.loadSources(context.Background(), sources, order); err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	errs := make([]error, 0)
+
+	for i, rule := range rules {
+		if isFileStruct(fields[i].Type()) {
+			errs = append(errs, validate(fields[i].Addr().Interface(), e.tagParser)...)
+
+			continue
+		}
+
+		// like the "required" tag, Required is satisfied by a default
+		if rule.Required && rule.Default == "" && fields[i].IsZero() {
+			errs = append(errs, &FieldRequiredError{FieldName: rule.Key})
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf(errMsg, &ValidationError{Errors: errs})
+	}
+
+	return nil
+}
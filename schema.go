@@ -0,0 +1,81 @@
+package envi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+const schemaResourceName = "envi-schema.json"
+
+/*
+LoadAndValidateSchema loads the config like Load does and validates the result against the given JSON schema.
+
+The config is marshalled to JSON before validation, so the property names in the schema have to match
+the JSON representation of the config struct (field names or "json" tags).
+
+Example:
+
+	//go:embed schema.json
+	var schema []byte
+
+	err := e.LoadAndValidateSchema(&myConfig, schema)
+*/
+func (e *Envi) LoadAndValidateSchema(config any, schema []byte) error {
+	const errMsg = "error while loading and validating config against schema: %w"
+
+	compiled, err := compileSchema(schema)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	if err := e.Load(config); err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	if err := validateSchema(compiled, config); err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	return nil
+}
+
+func compileSchema(schema []byte) (*jsonschema.Schema, error) {
+	const errMsg = "error while compiling schema: %w"
+
+	compiler := jsonschema.NewCompiler()
+
+	if err := compiler.AddResource(schemaResourceName, bytes.NewReader(schema)); err != nil {
+		return nil, fmt.Errorf(errMsg, err)
+	}
+
+	compiled, err := compiler.Compile(schemaResourceName)
+	if err != nil {
+		return nil, fmt.Errorf(errMsg, err)
+	}
+
+	return compiled, nil
+}
+
+func validateSchema(schema *jsonschema.Schema, config any) error {
+	const errMsg = "error while validating schema: %w"
+
+	blob, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	var doc any
+
+	if err := json.Unmarshal(blob, &doc); err != nil {
+		return fmt.Errorf(errMsg, &UnmarshalError{Type: "json", Err: err})
+	}
+
+	if err := schema.Validate(doc); err != nil {
+		return fmt.Errorf(errMsg, &ValidationSchemaError{Err: err})
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package envi
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+)
+
+/*
+SensitiveKeys returns the sorted environment variable names (values of the "env" tag) of all fields
+of the given config that are tagged with sensitive:"true". Nested structs are searched as well.
+
+Sensitive fields without an "env" tag are not included.
+*/
+func SensitiveKeys(config any) []string {
+	keys := make([]string, 0)
+
+	t := reflect.TypeOf(config)
+	if t == nil {
+		return keys
+	}
+
+	t = resolveTypePointer(t)
+	if t.Kind() != reflect.Struct {
+		return keys
+	}
+
+	keys = collectSensitiveKeys(t, keys)
+
+	slices.Sort(keys)
+
+	return slices.Compact(keys)
+}
+
+func collectSensitiveKeys(t reflect.Type, keys []string) []string {
+	for i := range t.NumField() {
+		field := t.Field(i)
+
+		if field.Type.Kind() == reflect.Struct {
+			keys = collectSensitiveKeys(field.Type, keys)
+		}
+
+		envTag := getStructTag(field, tagEnv)
+
+		if envTag != "" && boolTag(field, tagSensitive) {
+			keys = append(keys, envTag)
+		}
+	}
+
+	return keys
+}
+
+/*
+Redacted formats the config like fmt.Sprintf("%+v", config), but replaces the values of all fields
+tagged with sensitive:"true" with ***, so the config can be logged without leaking secrets.
+Nested structs are redacted as well, unexported fields are omitted.
+*/
+func (e *Envi) Redacted(config any) string {
+	v := reflect.ValueOf(config)
+	if !v.IsValid() {
+		return "<nil>"
+	}
+
+	return formatRedacted(v)
+}
+
+func formatRedacted(v reflect.Value) string {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "<nil>"
+		}
+
+		v = v.Elem()
+	}
+
+	if !isFileStruct(v.Type()) {
+		return formatValue(v)
+	}
+
+	t := v.Type()
+	parts := make([]string, 0, t.NumField())
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		value := maskedValue
+		if !boolTag(field, tagSensitive) {
+			value = formatRedacted(v.Field(i))
+		}
+
+		parts = append(parts, field.Name+":"+value)
+	}
+
+	return "{" + strings.Join(parts, " ") + "}"
+}
@@ -0,0 +1,175 @@
+package envi
+
+import (
+	"os"
+	"reflect"
+	"slices"
+)
+
+// prefixes of the source descriptors returned by LoadOrder.
+const (
+	sourceOverride = "override:"
+	sourceEnv      = "env:"
+	sourceDefault  = "default:"
+	sourceFile     = "file:"
+	sourceVault    = "vault:"
+)
+
+// kinds of sources a field value is taken from, see FieldInfo.
+const (
+	SourceOverride = "override"
+	SourceEnv      = "env"
+	SourceDefault  = "default"
+	SourceFile     = "file"
+	SourceVault    = "vault"
+)
+
+// sourceKey identifies a loaded field by its address. The type is part of the key because
+// the first field of a struct shares the address of the struct.
+type sourceKey struct {
+	addr uintptr
+	typ  reflect.Type
+}
+
+/*
+targetKey identifies a file or vault secret loaded into a particular field. Several fields, e.g. of configs loaded
+with BatchLoad, may be loaded from the same source, each of them is reloaded and watched on its own. The entries
+keyed by a targetKey hold the field, so its address cannot be reused by another field while they exist.
+*/
+type targetKey struct {
+	path  string
+	field sourceKey
+}
+
+func newTargetKey(path string, field reflect.Value) targetKey {
+	return targetKey{path: path, field: newSourceKey(field)}
+}
+
+/*
+LoadOrder returns the sources that were applied by Load in the order they were applied. The descriptors are:
+  - "override:<VAR_NAME>" for values set with SetEnvOverride
+  - "env:<VAR_NAME>" for environment variables that are set
+  - "default:<FieldPath>" for fields with a "default" tag, e.g. "default:Database.Port"
+  - "file:<absolute path>" for loaded files
+  - "vault:<mount>/<path>" for loaded vault secrets
+
+A field with both a set environment variable and a default records both sources.
+Repeated calls to Load append to the list. The returned slice is a copy.
+*/
+func (e *Envi) LoadOrder() []string {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	return slices.Clone(e.loadOrder)
+}
+
+/*
+resolveSource returns the kind of source the value of a field with the given tags is taken from, SourceOverride,
+SourceEnv or SourceDefault, or an empty string if none of them holds a value. Like getEnv, an override replaces
+the environment variable even if it is empty, the default is used then.
+*/
+func (e *Envi) resolveSource(envTag, defaultTag string) string {
+	if envTag != "" {
+		e.mutex.RLock()
+		override, overridden := e.overrides[envTag]
+		e.mutex.RUnlock()
+
+		switch {
+		case overridden && override != "":
+			return SourceOverride
+		case !overridden && os.Getenv(envTag) != "":
+			return SourceEnv
+		}
+	}
+
+	if defaultTag != "" {
+		return SourceDefault
+	}
+
+	return ""
+}
+
+// fieldSources returns the source descriptors of the value lookups for a single field.
+func (e *Envi) fieldSources(fieldName, envTag, defaultTag string) []string {
+	sources := make([]string, 0, 2)
+
+	switch e.resolveSource(envTag, defaultTag) {
+	case SourceOverride:
+		sources = append(sources, sourceOverride+envTag)
+	case SourceEnv:
+		sources = append(sources, sourceEnv+envTag)
+	}
+
+	if defaultTag != "" {
+		sources = append(sources, sourceDefault+fieldName)
+	}
+
+	return sources
+}
+
+// fieldSource returns the kind of source the value of a field is taken from.
+func (e *Envi) fieldSource(field reflect.Value, f reflect.StructField, envTag, defaultTag string) string {
+	if isFileStruct(field.Type()) {
+		if e.tagParser.TypeTag(f) == typeVault {
+			return SourceVault
+		}
+
+		return SourceFile
+	}
+
+	return e.resolveSource(envTag, defaultTag)
+}
+
+// fieldOrigin holds the kind of source of a loaded field and, for file structs, the path of the file or vault secret.
+type fieldOrigin struct {
+	source string
+	path   string
+}
+
+func newSourceKey(field reflect.Value) sourceKey {
+	return sourceKey{addr: field.Addr().Pointer(), typ: field.Type()}
+}
+
+/*
+configOrigins holds the origins of the fields of a loaded config. The origins are scoped to the config they were
+loaded into and replaced by every Load of it, so they neither grow with repeated loads nor outlive their config.
+The entry holds the config, so its address cannot be reused by another config while the entry exists.
+*/
+type configOrigins struct {
+	config reflect.Value
+	fields map[sourceKey]fieldOrigin
+}
+
+// recordOrigin remembers the origin of a loaded field for Inspect.
+func (s *sourceFields) recordOrigin(field reflect.Value, origin fieldOrigin) {
+	if s.origins == nil {
+		s.origins = make(map[sourceKey]fieldOrigin)
+	}
+
+	s.origins[newSourceKey(field)] = origin
+}
+
+// recordOrigins replaces the origins of the fields of the config with the origins recorded by its last Load.
+func (e *Envi) recordOrigins(config reflect.Value, origins map[sourceKey]fieldOrigin) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.origins[newSourceKey(config)] = configOrigins{config: config, fields: origins}
+}
+
+// loadedOrigins returns the origins of the fields recorded by the last Load of the config.
+func (e *Envi) loadedOrigins(config reflect.Value) map[sourceKey]fieldOrigin {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	return e.origins[newSourceKey(config)].fields
+}
+
+// loadedSource returns the origin of the field within the origins of its config.
+func loadedSource(origins map[sourceKey]fieldOrigin, field reflect.Value) fieldOrigin {
+	if !field.CanAddr() {
+		return fieldOrigin{}
+	}
+
+	return origins[newSourceKey(field)]
+}
@@ -0,0 +1,145 @@
+package envi
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+const redactedValue = "[REDACTED]"
+
+// tableBorder holds the characters used to draw the borders of a table.
+type tableBorder struct {
+	horizontal, vertical                  string
+	topLeft, topMiddle, topRight          string
+	middleLeft, middleMiddle, middleRight string
+	bottomLeft, bottomMiddle, bottomRight string
+}
+
+var (
+	plainBorder = tableBorder{
+		horizontal: "-", vertical: "|",
+		topLeft: "+", topMiddle: "+", topRight: "+",
+		middleLeft: "+", middleMiddle: "+", middleRight: "+",
+		bottomLeft: "+", bottomMiddle: "+", bottomRight: "+",
+	}
+
+	boxBorder = tableBorder{
+		horizontal: "─", vertical: "│",
+		topLeft: "┌", topMiddle: "┬", topRight: "┐",
+		middleLeft: "├", middleMiddle: "┼", middleRight: "┤",
+		bottomLeft: "└", bottomMiddle: "┴", bottomRight: "┘",
+	}
+)
+
+// tableRow holds the KEY, VALUE and SOURCE cells of a table row.
+type tableRow struct {
+	cells     [3]string
+	sensitive bool
+}
+
+/*
+ToTable writes the loaded values of the config as a table with the columns KEY, VALUE and SOURCE to w,
+sorted by key. The config has to be the pointer that was passed to Load, see Inspect.
+
+Like Keys, the KEY of a field is the name of its environment variable or, without an env tag, its dot separated
+path. The fields of file structs are keyed by their path, their SOURCE names the file, e.g. "file:./config.yaml".
+
+Values of sensitive fields are replaced with [REDACTED]. If w is a terminal, the table is drawn with
+box-drawing characters and redacted values are colored red, otherwise plain ASCII borders are used.
+*/
+func (e *Envi) ToTable(w io.Writer, config any) error {
+	const errMsg = "error while writing config table: %w"
+
+	fields := e.Inspect(config).Fields
+
+	rows := make([]tableRow, 0, len(fields))
+
+	for i, field := range fields {
+		// file structs are represented by the rows of their fields
+		if i+1 < len(fields) && strings.HasPrefix(fields[i+1].FieldName, field.FieldName+".") {
+			continue
+		}
+
+		value := field.ResolvedValue
+		if field.Sensitive {
+			value = redactedValue
+		}
+
+		key, source := field.FieldName, field.Source
+		if field.SourcePath != "" {
+			source += ":" + field.SourcePath
+		} else if field.EnvTag != "" {
+			key = e.envName(field.EnvTag)
+		}
+
+		rows = append(rows, tableRow{
+			cells:     [3]string{key, value, source},
+			sensitive: field.Sensitive,
+		})
+	}
+
+	slices.SortStableFunc(rows, func(a, b tableRow) int {
+		return strings.Compare(a.cells[0], b.cells[0])
+	})
+
+	header := tableRow{cells: [3]string{"KEY", "VALUE", "SOURCE"}}
+
+	widths := [3]int{}
+	for _, row := range append([]tableRow{header}, rows...) {
+		for col, cell := range row.cells {
+			widths[col] = max(widths[col], utf8.RuneCountInString(cell))
+		}
+	}
+
+	colored := IsTerminal(w)
+
+	border := plainBorder
+	if colored {
+		border = boxBorder
+	}
+
+	line := func(left, middle, right string) string {
+		segments := make([]string, len(widths))
+		for col, width := range widths {
+			segments[col] = strings.Repeat(border.horizontal, width+2)
+		}
+
+		return left + strings.Join(segments, middle) + right
+	}
+
+	format := func(row tableRow) string {
+		segments := make([]string, len(row.cells))
+		for col, cell := range row.cells {
+			segments[col] = fmt.Sprintf(" %-*s ", widths[col], cell)
+
+			if col == 1 && row.sensitive && colored {
+				segments[col] = colorRed + segments[col] + colorReset
+			}
+		}
+
+		return border.vertical + strings.Join(segments, border.vertical) + border.vertical
+	}
+
+	lines := []string{
+		line(border.topLeft, border.topMiddle, border.topRight),
+		format(header),
+		line(border.middleLeft, border.middleMiddle, border.middleRight),
+	}
+
+	for _, row := range rows {
+		lines = append(lines, format(row))
+	}
+
+	lines = append(lines, line(border.bottomLeft, border.bottomMiddle, border.bottomRight))
+
+	for _, l := range lines {
+		if _, err := fmt.Fprintln(w, l); err != nil {
+			return fmt.Errorf(errMsg, err)
+		}
+	}
+
+	return nil
+}
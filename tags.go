@@ -0,0 +1,141 @@
+package envi
+
+import (
+	"reflect"
+)
+
+// TagInfo holds the raw values of all envi struct tags of a single struct field.
+type TagInfo struct {
+	Env         string
+	Default     string
+	Type        string
+	Required    string
+	Watch       string
+	Sensitive   string
+	Description string
+	Alias       string
+	Separator   string
+	KVSeparator string
+	Format      string
+	Transform   string
+	Pattern     string
+	OneOf       string
+	Min         string
+	Max         string
+}
+
+/*
+ParseTag reads all envi struct tags of the given struct field into a TagInfo.
+It is meant for tooling (e.g. code generators or linters) that has to interpret envi-annotated structs.
+
+The Format field holds the value of the "layout" tag, the Separator field the value of the "sep" tag and
+the KVSeparator field the value of the "kvsep" tag.
+
+An InvalidTagError is returned if the "type" tag holds an unsupported file type or if one of the
+boolean tags ("required", "watch", "sensitive") holds another value than "true" or "false". Like Load,
+only "true" sets a boolean tag. An InvalidTransformError is
+returned if the "transform" tag holds an unknown transform and an InvalidTagError if the "pattern" tag
+holds an invalid regular expression.
+*/
+func ParseTag(field reflect.StructField) (TagInfo, error) {
+	info := TagInfo{
+		Env:         getStructTag(field, tagEnv),
+		Default:     getStructTag(field, tagDefault),
+		Type:        getStructTag(field, tagType),
+		Required:    getStructTag(field, tagRequired),
+		Watch:       getStructTag(field, tagWatch),
+		Sensitive:   getStructTag(field, tagSensitive),
+		Description: getStructTag(field, tagDescription),
+		Alias:       getStructTag(field, tagAlias),
+		Separator:   getStructTag(field, tagSeparator),
+		KVSeparator: getStructTag(field, tagKVSeparator),
+		Format:      getStructTag(field, tagLayout),
+		Transform:   getStructTag(field, tagTransform),
+		Pattern:     getStructTag(field, tagPattern),
+		OneOf:       getStructTag(field, tagOneOf),
+		Min:         getStructTag(field, tagMin),
+		Max:         getStructTag(field, tagMax),
+	}
+
+	if !isFileType(info.Type) {
+		return TagInfo{}, &InvalidTagError{Tag: tagType}
+	}
+
+	if _, err := parseTransforms(info.Transform); err != nil {
+		return TagInfo{}, err
+	}
+
+	if info.Pattern != "" {
+		if _, err := compilePattern(info.Pattern); err != nil {
+			return TagInfo{}, err
+		}
+	}
+
+	boolTags := []struct {
+		name  string
+		value string
+	}{
+		{name: tagRequired, value: info.Required},
+		{name: tagWatch, value: info.Watch},
+		{name: tagSensitive, value: info.Sensitive},
+	}
+
+	for _, tag := range boolTags {
+		if tag.value != "" && tag.value != "true" && tag.value != "false" {
+			return TagInfo{}, &InvalidTagError{Tag: tag.name}
+		}
+	}
+
+	return info, nil
+}
+
+// isFileType reports whether typeTag is empty or one of the supported values of the "type" tag.
+func isFileType(typeTag string) bool {
+	_, ok := unmarshalFuncs[typeTag]
+
+	return ok || typeTag == "" || typeTag == typeVault
+}
+
+// TagParser describes how envi reads the struct tags of a config field.
+// It can be replaced with WithTagParser to support other tag naming conventions.
+type TagParser interface {
+	EnvTag(f reflect.StructField) string
+	DefaultTag(f reflect.StructField) string
+	TypeTag(f reflect.StructField) string
+	RequiredTag(f reflect.StructField) bool
+	WatchTag(f reflect.StructField) bool
+}
+
+// DefaultTagParser reads the envi struct tags "env", "default", "type", "required" and "watch".
+// It can be embedded by custom parsers that only change some of the tags.
+type DefaultTagParser struct{}
+
+// EnvTag returns the value of the "env" tag.
+func (DefaultTagParser) EnvTag(f reflect.StructField) string {
+	return getStructTag(f, tagEnv)
+}
+
+// DefaultTag returns the value of the "default" tag.
+func (DefaultTagParser) DefaultTag(f reflect.StructField) string {
+	return getStructTag(f, tagDefault)
+}
+
+// TypeTag returns the value of the "type" tag.
+func (DefaultTagParser) TypeTag(f reflect.StructField) string {
+	return getStructTag(f, tagType)
+}
+
+// RequiredTag reports whether the "required" tag is set to true.
+func (DefaultTagParser) RequiredTag(f reflect.StructField) bool {
+	return boolTag(f, tagRequired)
+}
+
+// WatchTag reports whether the "watch" tag is set to true.
+func (DefaultTagParser) WatchTag(f reflect.StructField) bool {
+	return boolTag(f, tagWatch)
+}
+
+// boolTag reports whether the boolean tag is set. Only "true" sets it, ParseTag rejects values other than "true" and "false".
+func boolTag(f reflect.StructField, tagName string) bool {
+	return getStructTag(f, tagName) == "true"
+}
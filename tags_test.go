@@ -0,0 +1,235 @@
+package envi_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Clarilab/envi/v3"
+)
+
+func Test_ParseTag(t *testing.T) {
+	type Config struct {
+		Full         struct{} `env:"FULL" default:"./full.json" type:"json" required:"true" watch:"true" sensitive:"false" description:"a full field" alias:"OLD_FULL" sep:";" layout:"2006-01-02"`
+		Limited      int      `env:"LIMITED" oneof:"1,2,3" min:"1" max:"3"`
+		Empty        string
+		InvalidType  struct{} `env:"INVALID_TYPE" type:"xml"`
+		InvalidBool  string   `env:"INVALID_BOOL" required:"yes"`
+		NumericBool  string   `env:"NUMERIC_BOOL" required:"1"`
+		ShortBool    string   `env:"SHORT_BOOL" sensitive:"t"`
+		InvalidWatch struct{} `env:"INVALID_WATCH" watch:"sometimes"`
+	}
+
+	testCases := map[string]struct {
+		fieldName   string
+		expected    envi.TagInfo
+		expectedErr error
+	}{
+		"all tags are parsed": {
+			fieldName: "Full",
+			expected: envi.TagInfo{
+				Env:         "FULL",
+				Default:     "./full.json",
+				Type:        "json",
+				Required:    "true",
+				Watch:       "true",
+				Sensitive:   "false",
+				Description: "a full field",
+				Alias:       "OLD_FULL",
+				Separator:   ";",
+				Format:      "2006-01-02",
+			},
+			expectedErr: nil,
+		},
+		"validation tags are parsed": {
+			fieldName: "Limited",
+			expected: envi.TagInfo{
+				Env:   "LIMITED",
+				OneOf: "1,2,3",
+				Min:   "1",
+				Max:   "3",
+			},
+			expectedErr: nil,
+		},
+		"field without tags returns empty tag info": {
+			fieldName:   "Empty",
+			expected:    envi.TagInfo{},
+			expectedErr: nil,
+		},
+		"unsupported type returns error": {
+			fieldName:   "InvalidType",
+			expected:    envi.TagInfo{},
+			expectedErr: &envi.InvalidTagError{Tag: "type"},
+		},
+		"invalid required value returns error": {
+			fieldName:   "InvalidBool",
+			expected:    envi.TagInfo{},
+			expectedErr: &envi.InvalidTagError{Tag: "required"},
+		},
+		"numeric bool value returns error": {
+			fieldName:   "NumericBool",
+			expected:    envi.TagInfo{},
+			expectedErr: &envi.InvalidTagError{Tag: "required"},
+		},
+		"abbreviated bool value returns error": {
+			fieldName:   "ShortBool",
+			expected:    envi.TagInfo{},
+			expectedErr: &envi.InvalidTagError{Tag: "sensitive"},
+		},
+		"invalid watch value returns error": {
+			fieldName:   "InvalidWatch",
+			expected:    envi.TagInfo{},
+			expectedErr: &envi.InvalidTagError{Tag: "watch"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			field, ok := reflect.TypeOf(Config{}).FieldByName(tc.fieldName)
+			if !ok {
+				t.Fatalf("field %s not found", tc.fieldName)
+			}
+
+			info, err := envi.ParseTag(field)
+			switch {
+			case err != nil && tc.expectedErr == nil:
+				t.Errorf("expected no error but got %v", err)
+			case err == nil && tc.expectedErr != nil:
+				t.Errorf("expected error %v but got nil", tc.expectedErr)
+			case err != nil && tc.expectedErr != nil:
+				var tagErr *envi.InvalidTagError
+				if !errors.As(err, &tagErr) || err.Error() != tc.expectedErr.Error() {
+					t.Errorf("expected error %v but got %v", tc.expectedErr, err)
+				}
+			case err == nil && tc.expectedErr == nil:
+				if info != tc.expected {
+					t.Errorf("expected tag info %+v but got %+v", tc.expected, info)
+				}
+			}
+		})
+	}
+}
+
+// cfgTagParser reads the environment variable name from the "cfg" tag and the default from the "fallback" tag.
+type cfgTagParser struct {
+	envi.DefaultTagParser
+}
+
+func (cfgTagParser) EnvTag(f reflect.StructField) string {
+	return f.Tag.Get("cfg")
+}
+
+func (cfgTagParser) DefaultTag(f reflect.StructField) string {
+	return f.Tag.Get("fallback")
+}
+
+func (cfgTagParser) RequiredTag(f reflect.StructField) bool {
+	return f.Tag.Get("mandatory") == "yes"
+}
+
+func Test_WithTagParser(t *testing.T) {
+	type YAMLFile struct {
+		Shell string `yaml:"SHELL"`
+		Pager string `fallback:"less" yaml:"-"`
+	}
+
+	type Config struct {
+		Environment string   `cfg:"ENVIRONMENT" mandatory:"yes"`
+		ServiceName string   `cfg:"SERVICE_NAME" fallback:"envi-test"`
+		YAMLFile    YAMLFile `fallback:"./testdata/valid.yaml"`
+	}
+
+	testCases := map[string]struct {
+		envvars        map[string]string
+		expectedConfig Config
+		expectedErr    error
+	}{
+		"custom tags are used to load the config": {
+			envvars: map[string]string{
+				"ENVIRONMENT": "dev",
+			},
+			expectedConfig: Config{
+				Environment: "dev",
+				ServiceName: "envi-test",
+				YAMLFile: YAMLFile{
+					Shell: "csh",
+					Pager: "less",
+				},
+			},
+			expectedErr: nil,
+		},
+		"custom required tag is used for validation": {
+			envvars: nil,
+			expectedErr: &envi.ValidationError{
+				[]error{&envi.FieldRequiredError{
+					FieldName: "Environment",
+				}},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			for k, v := range tc.envvars {
+				t.Setenv(k, v)
+			}
+
+			var config Config
+
+			e := envi.New(envi.WithTagParser(cfgTagParser{}))
+
+			err := e.Load(&config)
+			switch {
+			case err != nil && tc.expectedErr == nil:
+				t.Errorf("expected no error but got %v", err)
+			case err == nil && tc.expectedErr != nil:
+				t.Errorf("expected error %v but got nil", tc.expectedErr)
+			case err != nil && tc.expectedErr != nil:
+				if errors.Unwrap(err).Error() != tc.expectedErr.Error() {
+					t.Errorf("expected error %v but got %v", tc.expectedErr, err)
+				}
+			case err == nil && tc.expectedErr == nil:
+				if config != tc.expectedConfig {
+					t.Errorf("expected config %+v but got %+v", tc.expectedConfig, config)
+				}
+			}
+		})
+	}
+
+	t.Run("dotenv keys are read with the custom parser", func(t *testing.T) {
+		type DotenvFile struct {
+			Host string `cfg:"DB_HOST"`
+			Port int    `cfg:"DB_PORT"`
+		}
+
+		type DotenvConfig struct {
+			Database DotenvFile `fallback:"./testdata/valid.env" type:"env"`
+		}
+
+		var config DotenvConfig
+
+		if err := envi.New(envi.WithTagParser(cfgTagParser{})).Load(&config); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := DotenvFile{Host: "localhost", Port: 5432}
+
+		if config.Database != expected {
+			t.Errorf("expected database %+v but got %+v", expected, config.Database)
+		}
+	})
+
+	t.Run("lint reads the tags with the custom parser", func(t *testing.T) {
+		if warnings := envi.New(envi.WithTagParser(cfgTagParser{})).Lint(&Config{}); len(warnings) != 0 {
+			t.Errorf("expected no warnings but got %+v", warnings)
+		}
+	})
+
+	t.Run("nil parser", func(t *testing.T) {
+		var config Config
+
+		if err := envi.New(envi.WithTagParser(nil)).Load(&config); !errors.Is(err, envi.ErrNilTagParser) {
+			t.Errorf("expected nil tag parser error but got %v", err)
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package envi
+
+import "strings"
+
+// transformFunc transforms a resolved string value before it is set on a field.
+type transformFunc func(string) string
+
+/*
+parseTransforms parses the comma separated list of the "transform" tag. Supported transforms are
+"trim", "upper", "lower", "trimprefix:<prefix>" and "trimsuffix:<suffix>".
+
+An InvalidTransformError is returned for unknown transforms.
+*/
+func parseTransforms(tag string) ([]transformFunc, error) {
+	if tag == "" {
+		return nil, nil
+	}
+
+	transforms := make([]transformFunc, 0)
+
+	for _, name := range strings.Split(tag, ",") {
+		name = strings.TrimSpace(name)
+
+		if prefix, ok := strings.CutPrefix(name, "trimprefix:"); ok {
+			transforms = append(transforms, func(s string) string { return strings.TrimPrefix(s, prefix) })
+
+			continue
+		}
+
+		if suffix, ok := strings.CutPrefix(name, "trimsuffix:"); ok {
+			transforms = append(transforms, func(s string) string { return strings.TrimSuffix(s, suffix) })
+
+			continue
+		}
+
+		switch name {
+		case "trim":
+			transforms = append(transforms, strings.TrimSpace)
+		case "upper":
+			transforms = append(transforms, strings.ToUpper)
+		case "lower":
+			transforms = append(transforms, strings.ToLower)
+		default:
+			return nil, &InvalidTransformError{Transform: name}
+		}
+	}
+
+	return transforms, nil
+}
+
+// transform applies the transforms of the "transform" tag to the value from left to right.
+func transform(value, tag string) (string, error) {
+	transforms, err := parseTransforms(tag)
+	if err != nil {
+		return "", err
+	}
+
+	for _, t := range transforms {
+		value = t(value)
+	}
+
+	return value, nil
+}
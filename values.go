@@ -0,0 +1,427 @@
+package envi
+
+import (
+	"cmp"
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultSeparator separates the elements of slice values if no "sep" tag is set.
+	defaultSeparator = ","
+	// defaultKVSeparator separates the key from the value of map entries if no "kvsep" tag is set.
+	defaultKVSeparator = "="
+	// defaultLayout is the layout of time.Time values if no "layout" tag is set.
+	defaultLayout = time.RFC3339
+)
+
+var (
+	ipNetType        = reflect.TypeOf(net.IPNet{})
+	hardwareAddrType = reflect.TypeOf(net.HardwareAddr{})
+	durationType     = reflect.TypeOf(time.Duration(0))
+	timeType         = reflect.TypeOf(time.Time{})
+	stringType       = reflect.TypeOf("")
+)
+
+// valueFormat holds the separators of slice elements and map entries and the layout of time values used by setValue.
+type valueFormat struct {
+	sep    string
+	kvSep  string
+	layout string
+}
+
+var defaultFormat = valueFormat{sep: defaultSeparator, kvSep: defaultKVSeparator, layout: defaultLayout}
+
+// fieldFormat returns the valueFormat set by the "sep", "kvsep" and "layout" tags of the struct field.
+func fieldFormat(f reflect.StructField) valueFormat {
+	return valueFormat{
+		sep:    cmp.Or(getStructTag(f, tagSeparator), defaultSeparator),
+		kvSep:  cmp.Or(getStructTag(f, tagKVSeparator), defaultKVSeparator),
+		layout: cmp.Or(getStructTag(f, tagLayout), defaultLayout),
+	}
+}
+
+// isNetType reports whether the given type is one of the supported types of the net package.
+func isNetType(t reflect.Type) bool {
+	return t == ipNetType || t == hardwareAddrType
+}
+
+// isFileStruct reports whether the given type is a struct that gets loaded from a file.
+func isFileStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && !isNetType(t) && t != timeType
+}
+
+// isParsable reports whether a value of the given type can be parsed from a string by setValue.
+func isParsable(t reflect.Type) bool {
+	if isNetType(t) || t == timeType {
+		return true
+	}
+
+	if t.Kind() == reflect.Slice {
+		return t.Elem().Kind() != reflect.Slice && t.Elem().Kind() != reflect.Map && isParsable(t.Elem())
+	}
+
+	if t.Kind() == reflect.Map {
+		return t.Key().Kind() == reflect.String &&
+			t.Elem().Kind() != reflect.Slice && t.Elem().Kind() != reflect.Map && isParsable(t.Elem())
+	}
+
+	switch t.Kind() {
+	case reflect.String, reflect.Bool, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	default:
+		return false
+	}
+}
+
+// setValue parses the string value into the type of the given field and sets it.
+func setValue(field reflect.Value, value string) error {
+	return setValueFormat(field, value, defaultFormat)
+}
+
+// setValueFormat is like setValue, but splits the values of slice and map fields at the separators of the format
+// and parses time values with its layout.
+func setValueFormat(field reflect.Value, value string, format valueFormat) error {
+	switch field.Type() {
+	case ipNetType:
+		_, ipNet, err := net.ParseCIDR(value)
+		if err != nil {
+			return &ParsingError{Type: "net.IPNet", Err: err}
+		}
+
+		field.Set(reflect.ValueOf(*ipNet))
+
+		return nil
+	case hardwareAddrType:
+		mac, err := net.ParseMAC(value)
+		if err != nil {
+			return &ParsingError{Type: "net.HardwareAddr", Err: err}
+		}
+
+		field.Set(reflect.ValueOf(mac))
+
+		return nil
+	case durationType:
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return &ParsingError{Type: "time.Duration", Err: err}
+		}
+
+		field.SetInt(int64(duration))
+
+		return nil
+	case timeType:
+		t, err := time.Parse(format.layout, value)
+		if err != nil {
+			return &ParsingError{Type: "time.Time", Err: err}
+		}
+
+		field.Set(reflect.ValueOf(t))
+
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.Slice:
+		return setSlice(field, value, format)
+	case reflect.Map:
+		return setMap(field, value, format)
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsedInt, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return &ParsingError{Type: "int", Err: err}
+		}
+
+		field.SetInt(parsedInt)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsedUint, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return &ParsingError{Type: "uint", Err: err}
+		}
+
+		field.SetUint(parsedUint)
+	case reflect.Float32, reflect.Float64:
+		parsedFloat, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return &ParsingError{Type: "float", Err: err}
+		}
+
+		field.SetFloat(parsedFloat)
+	case reflect.Bool:
+		parsedBool, err := strconv.ParseBool(value)
+		if err != nil {
+			return &ParsingError{Type: "bool", Err: err}
+		}
+
+		field.SetBool(parsedBool)
+	default:
+		return &InvalidKindError{
+			FieldName: field.Type().Name(),
+			Expected:  "string, int, uint, float, bool, slice, map, time.Time, net.IPNet, net.HardwareAddr",
+			Got:       field.Kind().String(),
+		}
+	}
+
+	return nil
+}
+
+// setSlice splits the string value at the separator of the format and sets the parsed elements as the value
+// of the given slice field. Whitespace around the elements is trimmed.
+func setSlice(field reflect.Value, value string, format valueFormat) error {
+	parts := strings.Split(value, format.sep)
+	slice := reflect.MakeSlice(field.Type(), len(parts), len(parts))
+
+	for i, part := range parts {
+		if err := setValueFormat(slice.Index(i), strings.TrimSpace(part), format); err != nil {
+			return err
+		}
+	}
+
+	field.Set(slice)
+
+	return nil
+}
+
+/*
+setMap splits the string value at the separator of the format into entries and each entry at the key value
+separator of the format, e.g. "app=myapp,env=prod", and sets the parsed values as the value of the given map
+field. Whitespace around keys and values is trimmed and empty entries are skipped.
+*/
+func setMap(field reflect.Value, value string, format valueFormat) error {
+	m := reflect.MakeMap(field.Type())
+
+	for _, entry := range strings.Split(value, format.sep) {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		key, val, ok := strings.Cut(entry, format.kvSep)
+		if !ok {
+			return &ParsingError{
+				Type: "map",
+				Err:  fmt.Errorf("missing key value separator %q in entry %q", format.kvSep, entry),
+			}
+		}
+
+		elem := reflect.New(field.Type().Elem()).Elem()
+
+		if err := setValueFormat(elem, strings.TrimSpace(val), format); err != nil {
+			return err
+		}
+
+		m.SetMapIndex(reflect.ValueOf(strings.TrimSpace(key)).Convert(field.Type().Key()), elem)
+	}
+
+	field.Set(m)
+
+	return nil
+}
+
+/*
+netTextUnmarshalFunc wraps the unmarshal function of a file format that cannot decode net.IPNet and
+net.HardwareAddr values from their text form, e.g. cidr: "10.0.0.0/8". If the target contains such values,
+the file is unmarshalled into a copy of the target in which they are strings, which are parsed like environment
+variables afterwards.
+*/
+func netTextUnmarshalFunc(unmarshal unmarshalFunc) unmarshalFunc {
+	return func(data []byte, v any) error {
+		target := reflect.ValueOf(v).Elem()
+
+		textType, ok := netTextType(target.Type(), make(map[reflect.Type]bool))
+		if !ok {
+			return unmarshal(data, v)
+		}
+
+		text := reflect.New(textType)
+
+		// the copy starts with the current values, so fields missing in the file keep their defaults
+		if err := convertNetText(text.Elem(), target); err != nil {
+			return err
+		}
+
+		if err := unmarshal(data, text.Interface()); err != nil {
+			return err
+		}
+
+		return convertNetText(target, text.Elem())
+	}
+}
+
+/*
+netTextType returns a copy of t in which net.IPNet and net.HardwareAddr are replaced by string. ok is false
+if t contains neither of them. Unexported struct fields are left out of the copy, seen guards against recursive types.
+*/
+func netTextType(t reflect.Type, seen map[reflect.Type]bool) (reflect.Type, bool) {
+	if isNetType(t) {
+		return stringType, true
+	}
+
+	if seen[t] {
+		return t, false
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		elem, ok := netTextType(t.Elem(), seen)
+
+		return reflect.PointerTo(elem), ok
+	case reflect.Slice:
+		elem, ok := netTextType(t.Elem(), seen)
+
+		return reflect.SliceOf(elem), ok
+	case reflect.Array:
+		elem, ok := netTextType(t.Elem(), seen)
+
+		return reflect.ArrayOf(t.Len(), elem), ok
+	case reflect.Map:
+		elem, ok := netTextType(t.Elem(), seen)
+
+		return reflect.MapOf(t.Key(), elem), ok
+	case reflect.Struct:
+		if t == timeType {
+			return t, false
+		}
+
+		seen[t] = true
+		defer delete(seen, t)
+
+		fields := make([]reflect.StructField, 0, t.NumField())
+		changed := false
+
+		for i := range t.NumField() {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+
+			fieldType, ok := netTextType(f.Type, seen)
+			changed = changed || ok
+
+			fields = append(fields, reflect.StructField{
+				Name: f.Name,
+				Type: fieldType,
+				Tag:  f.Tag,
+				// reflect.StructOf cannot embed types with methods, they are kept as named fields
+				Anonymous: f.Anonymous && fieldType.NumMethod() == 0 && reflect.PointerTo(fieldType).NumMethod() == 0,
+			})
+		}
+
+		if !changed {
+			return t, false
+		}
+
+		return reflect.StructOf(fields), true
+	default:
+		return t, false
+	}
+}
+
+// convertNetText copies src to dst, whose types only differ in the net values replaced by netTextType.
+// Net values are formatted to or parsed from their text form, an empty string is the zero value.
+func convertNetText(dst, src reflect.Value) error {
+	if dst.Type() == src.Type() {
+		dst.Set(src)
+
+		return nil
+	}
+
+	switch {
+	case isNetType(src.Type()):
+		dst.SetString(formatNet(src))
+
+		return nil
+	case isNetType(dst.Type()):
+		if src.String() == "" {
+			dst.Set(reflect.Zero(dst.Type()))
+
+			return nil
+		}
+
+		return setValue(dst, src.String())
+	}
+
+	switch src.Kind() {
+	case reflect.Pointer:
+		if src.IsNil() {
+			dst.Set(reflect.Zero(dst.Type()))
+
+			return nil
+		}
+
+		dst.Set(reflect.New(dst.Type().Elem()))
+
+		return convertNetText(dst.Elem(), src.Elem())
+	case reflect.Slice:
+		if src.IsNil() {
+			dst.Set(reflect.Zero(dst.Type()))
+
+			return nil
+		}
+
+		dst.Set(reflect.MakeSlice(dst.Type(), src.Len(), src.Len()))
+
+		fallthrough
+	case reflect.Array:
+		for i := range src.Len() {
+			if err := convertNetText(dst.Index(i), src.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if src.IsNil() {
+			dst.Set(reflect.Zero(dst.Type()))
+
+			return nil
+		}
+
+		dst.Set(reflect.MakeMapWithSize(dst.Type(), src.Len()))
+
+		iter := src.MapRange()
+		for iter.Next() {
+			value := reflect.New(dst.Type().Elem()).Elem()
+
+			if err := convertNetText(value, iter.Value()); err != nil {
+				return err
+			}
+
+			dst.SetMapIndex(iter.Key(), value)
+		}
+	case reflect.Struct:
+		for i := range src.NumField() {
+			f := src.Type().Field(i)
+			if !f.IsExported() {
+				continue
+			}
+
+			if err := convertNetText(dst.FieldByName(f.Name), src.Field(i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// formatNet returns the text form of a net.IPNet or net.HardwareAddr value, or an empty string if it is unset.
+func formatNet(v reflect.Value) string {
+	switch value := v.Interface().(type) {
+	case net.IPNet:
+		if value.IP == nil {
+			return ""
+		}
+
+		return value.String()
+	case net.HardwareAddr:
+		return value.String()
+	default:
+		return ""
+	}
+}
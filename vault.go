@@ -0,0 +1,232 @@
+package envi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+const (
+	typeVault = "vault"
+
+	envVaultAddr  = "VAULT_ADDR"
+	envVaultToken = "VAULT_TOKEN"
+
+	defaultVaultPollInterval = time.Minute
+	vaultRequestTimeout      = 30 * time.Second
+)
+
+/*
+vaultClient reads secrets from the KV v2 secrets engine of a HashiCorp Vault server. If apiClient is set,
+the secrets are read with it, otherwise they are requested from address with httpClient.
+*/
+type vaultClient struct {
+	address    string
+	token      string
+	httpClient *http.Client
+	apiClient  *api.Client
+}
+
+// vaultField describes a struct field of the config that is loaded from vault. Like for a fileField, the hash
+// of the secret last loaded into the field is shared by all copies.
+type vaultField struct {
+	field reflect.Value
+	path  string
+	watch bool
+	hash  *string
+}
+
+// newVaultClient creates a vault client that sends its requests with httpClient, or with a default client if it is nil.
+func newVaultClient(address, token string, httpClient *http.Client) *vaultClient {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: vaultRequestTimeout}
+	}
+
+	return &vaultClient{
+		address:    strings.TrimSuffix(address, "/"),
+		token:      token,
+		httpClient: httpClient,
+	}
+}
+
+// getVaultClient returns the configured vault client or creates one from the VAULT_ADDR and VAULT_TOKEN environment variables.
+func (e *Envi) getVaultClient() (*vaultClient, error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.vault != nil {
+		return e.vault, nil
+	}
+
+	address := os.Getenv(envVaultAddr)
+	token := os.Getenv(envVaultToken)
+
+	if address == "" || token == "" {
+		return nil, &VaultNotConfiguredError{}
+	}
+
+	e.vault = newVaultClient(address, token, e.vaultHTTPClient)
+
+	return e.vault, nil
+}
+
+/*
+readSecret reads the data of the secret at the given path. The first path segment is the mount
+of the KV v2 secrets engine, the rest is the path of the secret, e.g. "secret/my-app/database".
+*/
+func (c *vaultClient) readSecret(ctx context.Context, path string) ([]byte, error) {
+	mount, secretPath, ok := strings.Cut(strings.Trim(path, "/"), "/")
+	if !ok || mount == "" || secretPath == "" {
+		return nil, &InvalidVaultPathError{Path: path}
+	}
+
+	if c.apiClient != nil {
+		return readKVSecret(ctx, c.apiClient, mount, secretPath)
+	}
+
+	url := fmt.Sprintf("%s/v1/%s/data/%s", c.address, mount, secretPath)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("X-Vault-Token", c.token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &VaultRequestError{Path: path, StatusCode: resp.StatusCode}
+	}
+
+	blob, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var secret struct {
+		Data struct {
+			Data json.RawMessage `json:"data"`
+		} `json:"data"`
+	}
+
+	if err := json.Unmarshal(blob, &secret); err != nil {
+		return nil, &UnmarshalError{Type: "json", Err: err}
+	}
+
+	return secret.Data.Data, nil
+}
+
+// readKVSecret reads the data of the secret with the KV v2 helper of a vault API client.
+func readKVSecret(ctx context.Context, client *api.Client, mount, secretPath string) ([]byte, error) {
+	secret, err := client.KVv2(mount).Get(ctx, secretPath)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(secret.Data)
+	if err != nil {
+		return nil, &UnmarshalError{Type: "json", Err: err}
+	}
+
+	return data, nil
+}
+
+// loadVault loads the secret into the field, if it changed since it was loaded into the field the last time.
+func (e *Envi) loadVault(ctx context.Context, vault vaultField) (bool, error) {
+	const errMsg = "error while loading vault secret: %w"
+
+	path, field := vault.path, vault.field
+
+	client, err := e.getVaultClient()
+	if err != nil {
+		return false, fmt.Errorf(errMsg, err)
+	}
+
+	data, err := client.readSecret(ctx, path)
+	if err != nil {
+		return false, fmt.Errorf(errMsg, err)
+	}
+
+	newHash := e.hashAlgo.sum(data)
+
+	if *vault.hash != "" && newHash == *vault.hash {
+		return false, nil // the secret has not changed, do not run trigger
+	}
+
+	if err := handleDefaults(field, e.tagParser); err != nil {
+		return false, fmt.Errorf(errMsg, err)
+	}
+
+	if err := unmarshalFuncs["json"](data, field.Addr().Interface()); err != nil {
+		return false, fmt.Errorf(errMsg, &UnmarshalError{Type: "json", Err: err})
+	}
+
+	// like for files, the hash is stored only after a successful load, so a failed load is retried on the next poll
+	*vault.hash = newHash
+
+	return true, nil
+}
+
+// watchVault polls the secret for changes in the configured interval.
+func (e *Envi) watchVault(vault vaultField) {
+	ctx, cancel := context.WithCancel(e.ctx)
+
+	e.replaceWatcher(newTargetKey(sourceVault+vault.path, vault.field), fileWatcherInstance{
+		ctx:    ctx,
+		cancel: cancel,
+	})
+
+	go e.vaultWatcher(ctx, vault)
+}
+
+func (e *Envi) vaultWatcher(ctx context.Context, vault vaultField) {
+	const errMsg = "error reloading watched vault secret: %w"
+
+	field, path := vault.field, vault.path
+
+	callback, ok := field.Addr().Interface().(FileWatcher)
+	if !ok {
+		return
+	}
+
+	ticker := time.NewTicker(e.vaultPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			e.logf("watcher for vault secret %s stopping", path)
+
+			return
+		case <-ticker.C:
+			if e.paused.Load() {
+				continue
+			}
+
+			err := e.reloadSource(field, sourceVault+path, func() (bool, error) {
+				return e.loadVault(ctx, vault)
+			})
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+
+				e.watchError(callback, sourceVault+path, fmt.Errorf(errMsg, err))
+			}
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package envi
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// warmFile holds the content and hash of a file that was read ahead of Load, as well as the modification time
+// and size of the file at that point to detect later changes.
+type warmFile struct {
+	blob    []byte
+	hash    string
+	modTime time.Time
+	size    int64
+}
+
+/*
+WarmUp concurrently reads and hashes the given files before Load is called.
+The next Load uses the cached content of these files instead of reading them again, unless a file was modified
+since. The cache is dropped at the end of the next Load or LoadRules, also for files the config does not use.
+
+This is an optimization for configs with many file-backed fields. Load works the same without calling WarmUp.
+Files that cannot be read are skipped, their errors are returned combined in a WarmUpError.
+*/
+func (e *Envi) WarmUp(paths []string) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+
+	for _, path := range paths {
+		wg.Add(1)
+
+		go func(path string) {
+			defer wg.Done()
+
+			absPath, file, err := readWarmFile(path, e.hashAlgo)
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+
+				return
+			}
+
+			e.mutex.Lock()
+			e.warmFiles[absPath] = file
+			e.mutex.Unlock()
+		}(path)
+	}
+
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return &WarmUpError{Errors: errs}
+	}
+
+	return nil
+}
+
+func readWarmFile(path string, algo HashAlgo) (string, warmFile, error) {
+	const errMsg = "failed to warm up file %s with error: %w"
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", warmFile{}, fmt.Errorf(errMsg, path, err)
+	}
+
+	// the file is stat'ed before it is read, so a change while reading is detected by the next stat
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return "", warmFile{}, fmt.Errorf(errMsg, path, err)
+	}
+
+	blob, err := os.ReadFile(absPath)
+	if err != nil {
+		return "", warmFile{}, fmt.Errorf(errMsg, path, err)
+	}
+
+	return absPath, warmFile{blob: blob, hash: algo.sum(blob), modTime: info.ModTime(), size: info.Size()}, nil
+}
+
+// isCurrent reports whether the file at path has not been modified since it was warmed up.
+func (f warmFile) isCurrent(path string) bool {
+	info, err := os.Stat(path)
+
+	return err == nil && info.ModTime().Equal(f.modTime) && info.Size() == f.size
+}
+
+// dropWarmFiles drops the files cached by WarmUp, including those that were not loaded.
+func (e *Envi) dropWarmFiles() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	clear(e.warmFiles)
+}
+
+// readFile returns the content and hash of the file at path. The error of the context is returned if it is
+// done before the file is read. Files cached by WarmUp are served from the cache once if they were not modified
+// since, and read from disk afterwards.
+func (e *Envi) readFile(ctx context.Context, path string) ([]byte, string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
+
+	e.mutex.Lock()
+	file, ok := e.warmFiles[path]
+	delete(e.warmFiles, path)
+	e.mutex.Unlock()
+
+	if ok && file.isCurrent(path) {
+		return file.blob, file.hash, nil
+	}
+
+	type result struct {
+		blob []byte
+		err  error
+	}
+
+	// os.ReadFile cannot be cancelled, the buffered channel lets the goroutine finish after a cancellation
+	done := make(chan result, 1)
+
+	go func() {
+		blob, err := os.ReadFile(path)
+		done <- result{blob: blob, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, "", ctx.Err()
+	case r := <-done:
+		if r.err != nil {
+			return nil, "", r.err
+		}
+
+		return r.blob, e.hashAlgo.sum(r.blob), nil
+	}
+}